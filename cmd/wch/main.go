@@ -1,15 +1,30 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
 
+	"github.com/ivoronin/wch/internal/diff"
+	"github.com/ivoronin/wch/internal/events"
+	"github.com/ivoronin/wch/internal/exitcond"
+	"github.com/ivoronin/wch/internal/headless"
 	"github.com/ivoronin/wch/internal/recording"
+	"github.com/ivoronin/wch/internal/runner"
+	"github.com/ivoronin/wch/internal/schedule"
+	"github.com/ivoronin/wch/internal/session"
+	"github.com/ivoronin/wch/internal/trigger"
 	"github.com/ivoronin/wch/internal/tui"
 )
 
@@ -17,16 +32,49 @@ var version = "dev"
 
 func main() {
 	interval := flag.Duration("i", time.Second, "refresh interval")
-	historyLimit := flag.Int("l", 86400, "history limit (executions retained in memory; 0 = unlimited)")
+	scheduleSpec := flag.String("schedule", "", "run on a `schedule` instead of -i: a cron expression (\"*/5 * * * *\", \"@hourly\") or an interval with jitter (\"30s±5s\")")
+	backoffMax := flag.Duration("backoff", 0, "back off from -i up to `max` while the command fails or its output stays the same (0 = never)")
+	precise := flag.Bool("precise", false, "run on wall-clock multiples of the interval, however long each run takes")
+	overlapName := flag.String("overlap", "", "with -precise or -on-change, what a tick or change does while the previous run is still going: skip, queue or concurrent (default skip; queue with -on-change)")
+	var onChange pathList
+	flag.Var(&onChange, "on-change", "run when a file under `path` changes (repeatable; directories are watched recursively; -i then sets a fallback poll)")
+	historyLimit := flag.Int("l", 0, "history limit (executions retained; 0 = unlimited, within -history-bytes)")
+	historyBytes, spool := byteSize(256<<20), byteSize(0)
+	flag.Var(&historyBytes, "history-bytes", "limit history to `size` (500M, 2G; 0 = unlimited), evicting the oldest executions; lines repeated across executions are stored once; with -spool, unlimited unless given")
+	flag.Var(&spool, "spool", "keep only `size` of history output in memory (64M), spilling older output to a temporary file; spooled output is stored as is, without sharing repeated lines (0 = keep it all in memory)")
 	disableDiff := flag.Bool("d", false, "disable diff highlighting")
+	showGhosts := flag.Bool("ghosts", false, "show deleted lines as ghost rows in the diff view")
+	showDeltas := flag.Bool("deltas", false, "annotate changed numbers, sizes and durations with their delta in the diff view")
+	baseline := flag.String("baseline", "", "diff every frame against a pinned `frame` instead of the previous one (first)")
+	var ignore patternList
+	flag.Var(&ignore, "ignore", "ignore text matching `regexp` when detecting and highlighting changes (repeatable)")
+	keySpec := flag.String("key", "", "identify rows by these `columns` (numbers from 1 or header names, comma-separated)")
+	var execArgv bool
+	flag.BoolVar(&execArgv, "x", false, "run the command's arguments directly, without a shell")
+	flag.BoolVar(&execArgv, "exec", false, "same as -x")
+	shell := flag.String("shell", "", "run the command with this `interpreter` (bash, zsh, fish) instead of sh")
+	timeout := flag.Duration("timeout", 0, "kill the command and its children when a run takes longer than `duration` (0 = never)")
+	stream := flag.Bool("stream", false, "keep one long-running command (kubectl get -w, tail -f) and snapshot its output as it arrives and every interval")
+	streamLines := flag.Int("stream-lines", 0, "with -stream, keep only the last `n` lines of output (0 = all)")
+	usePTY := flag.Bool("pty", false, "run the command under a pseudo-terminal sized to the view, keeping its colours (Linux only)")
 	hideStatus := flag.Bool("t", false, "hide status bar")
 	enableNotify := flag.Bool("b", false, "enable terminal notification on change")
 	openPath := flag.String("r", "", "read a recorded session in replay mode (offline)")
-	writePath := flag.String("w", "", "write a recording to <path> (started immediately; file must not already exist)")
+	writePath := flag.String("w", "", "write a recording to <path> (started immediately; file must not already exist; gzipped when it ends in .gz)")
+	compress := flag.Bool("compress", false, "gzip recordings whatever their file name")
+	exitOnChange := flag.Bool("g", false, "exit when the output changes")
+	untilMatch := flag.String("until-match", "", "exit when the output matches `regexp`")
+	whileMatch := flag.String("while-match", "", "exit when the output stops matching `regexp`")
+	untilSuccess := flag.Bool("until-success", false, "exit when the command exits zero")
+	untilFailure := flag.Bool("until-failure", false, "exit when the command exits non-zero")
+	commandsFile := flag.String("commands", "", "watch each command in `file` (one per line) alongside any given on the command line")
+	headlessMode := flag.Bool("headless", false, "run without the TUI, printing a change log to stdout")
+	eventsFormat := flag.String("events", "", "write an event stream in `format` (json)")
+	eventsOut := flag.String("events-out", "", "write the event stream to `path` instead of stdout")
 	showVersion := flag.Bool("version", false, "show version")
 
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: wch [flags] <command>\n       wch -r <file>\n\nFlags:\n")
+		fmt.Fprintf(os.Stderr, "Usage: wch [flags] <command>\n       wch [flags] -- <command> -- <command>...\n       wch -r <file>\n\nFlags:\n")
 		flag.PrintDefaults()
 	}
 
@@ -43,17 +91,145 @@ func main() {
 		os.Exit(1)
 	}
 
+	align := diff.Options{Ignore: ignore}
+	if *keySpec != "" {
+		key, err := diff.ParseKey(*keySpec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -key: %v\n", err)
+			os.Exit(1)
+		}
+		align.Key = key
+	}
+
+	exit := exitcond.Conditions{
+		OnChange:     *exitOnChange,
+		UntilMatch:   mustCompile("-until-match", *untilMatch),
+		WhileMatch:   mustCompile("-while-match", *whileMatch),
+		UntilSuccess: *untilSuccess,
+		UntilFailure: *untilFailure,
+	}
+
+	if execArgv && *shell != "" {
+		fmt.Fprintln(os.Stderr, "Error: -x and -shell are mutually exclusive")
+		flag.Usage()
+		os.Exit(1)
+	}
+	// A change that lands while a run is going must not be lost, so -on-change queues
+	// by default.
+	defaultOverlap := "skip"
+	if len(onChange) > 0 {
+		defaultOverlap = "queue"
+	}
+	overlap, err := schedule.ParsePolicy(cmp.Or(*overlapName, defaultOverlap))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -overlap: %v\n", err)
+		os.Exit(1)
+	}
+	if *overlapName != "" && !*precise && len(onChange) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: -overlap requires -precise or -on-change")
+		os.Exit(1)
+	}
+	// With -on-change, -i is an optional fallback poll: without it, only changes (and
+	// the first run at launch) run the command.
+	if len(onChange) > 0 && !flagSet("i") {
+		*interval = 0
+	}
+	// Wall-clock multiples of no interval would all be due at once.
+	if *precise && *scheduleSpec == "" && *interval <= 0 {
+		fmt.Fprintln(os.Stderr, "Error: -precise needs a positive -i")
+		os.Exit(1)
+	}
+	var sched schedule.Scheduler
+	if *scheduleSpec != "" {
+		if flagSet("i") {
+			fmt.Fprintln(os.Stderr, "Error: -i and -schedule are mutually exclusive")
+			os.Exit(1)
+		}
+		if sched, err = schedule.Parse(*scheduleSpec); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: -schedule: %v\n", err)
+			os.Exit(1)
+		}
+		// The session's interval is what recordings report: the nominal one of a
+		// jittered schedule, none for cron.
+		switch sc := sched.(type) {
+		case schedule.Jittered:
+			*interval = sc.Interval
+		case schedule.Every:
+			*interval = time.Duration(sc)
+		default:
+			*interval = 0
+		}
+	}
+	newSched := func() schedule.Scheduler { return sched }
+	if *backoffMax > 0 {
+		if *scheduleSpec != "" || *precise {
+			fmt.Fprintln(os.Stderr, "Error: -backoff is exclusive with -schedule and -precise")
+			os.Exit(1)
+		}
+		if *interval == 0 {
+			fmt.Fprintln(os.Stderr, "Error: -backoff with -on-change needs -i for the poll it backs off")
+			os.Exit(1)
+		}
+		if *backoffMax < *interval {
+			fmt.Fprintf(os.Stderr, "Error: -backoff: %v is shorter than the interval (%v)\n", *backoffMax, *interval)
+			os.Exit(1)
+		}
+		// A Backoff adapts to the results it observes, so every pane gets its own.
+		newSched = func() schedule.Scheduler { return schedule.NewBackoff(*interval, *backoffMax) }
+	}
+	if *stream && (*usePTY || *timeout > 0 || len(onChange) > 0) {
+		fmt.Fprintln(os.Stderr, "Error: -stream is exclusive with -pty, -timeout and -on-change")
+		os.Exit(1)
+	}
+	if *usePTY && !runner.PTYSupported {
+		fmt.Fprintln(os.Stderr, "Error: -pty is only supported on Linux")
+		os.Exit(1)
+	}
+	if *streamLines < 0 {
+		fmt.Fprintln(os.Stderr, "Error: -stream-lines must not be negative")
+		os.Exit(1)
+	}
+	if *streamLines != 0 && !*stream {
+		fmt.Fprintln(os.Stderr, "Error: -stream-lines requires -stream")
+		os.Exit(1)
+	}
+	if *baseline != "" && *baseline != "first" {
+		fmt.Fprintf(os.Stderr, "Error: -baseline: unsupported value %q (supported: first)\n", *baseline)
+		os.Exit(1)
+	}
+	if *eventsFormat != "" && *eventsFormat != "json" {
+		fmt.Fprintf(os.Stderr, "Error: -events: unsupported format %q (supported: json)\n", *eventsFormat)
+		os.Exit(1)
+	}
+	if *eventsOut != "" && *eventsFormat == "" {
+		fmt.Fprintln(os.Stderr, "Error: -events-out requires -events")
+		os.Exit(1)
+	}
+	// The default -history-bytes bounds memory. A spooled history keeps its outputs on disk,
+	// where that default would only throw away what -spool was asked to keep; an explicit
+	// -history-bytes still caps it, output on disk included.
+	if spool > 0 && !flagSet("history-bytes") {
+		historyBytes = 0
+	}
+
 	var model tea.Model
+	var sink *events.Sink
+	var sinkFile *os.File // nil when the stream goes to stdout
 
 	if *openPath != "" {
-		if len(flag.Args()) > 0 {
+		if exit.Enabled() || *headlessMode || *eventsFormat != "" || len(onChange) > 0 {
+			fmt.Fprintln(os.Stderr, "Error: -headless, -events, -on-change and exit conditions require a command")
+			flag.Usage()
+			os.Exit(1)
+		}
+		if len(flag.Args()) > 0 || *commandsFile != "" {
 			fmt.Fprintln(os.Stderr, "Error: -r is exclusive with a command")
 			flag.Usage()
 			os.Exit(1)
 		}
-		s, err := recording.Load(*openPath)
+		streams, err := recording.LoadStreams(*openPath)
 		if err != nil {
-			if s == nil {
+			if streams == nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
@@ -61,15 +237,41 @@ func main() {
 			// with the frames that did decode.
 			fmt.Fprintf(os.Stderr, "wch: warning: %v\n", err)
 		}
-		model = tui.NewReplay(tui.Config{
-			Command:     s.Command,
-			Interval:    s.Interval,
-			DiffEnabled: !*disableDiff,
-			ShowStatus:  !*hideStatus,
-		}, s)
+		panes := make([]tui.Model, len(streams))
+		for i, s := range streams {
+			panes[i] = tui.NewReplay(tui.Config{
+				Command:       s.Command,
+				Interval:      s.Interval,
+				DiffEnabled:   !*disableDiff,
+				ShowGhosts:    *showGhosts,
+				ShowDeltas:    *showDeltas,
+				BaselineFirst: *baseline == "first",
+				Align:         align,
+				ShowStatus:    !*hideStatus,
+			}, s)
+		}
+		model = panes[0]
+		if len(panes) > 1 {
+			model = tui.NewMulti(panes)
+		}
 	} else {
-		args := flag.Args()
-		if len(args) == 0 {
+		commands := [][]string{flag.Args()}
+		if n := len(os.Args) - flag.NArg(); os.Args[n-1] == "--" {
+			// "wch -- a -- b": "--" separates commands only when one ends the flags, so
+			// "wch git log -- path" stays one command.
+			commands = splitCommands(flag.Args())
+		} else if flag.NArg() == 0 {
+			commands = nil
+		}
+		if *commandsFile != "" {
+			lines, err := readCommands(*commandsFile, execArgv)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: -commands: %v\n", err)
+				os.Exit(1)
+			}
+			commands = append(commands, lines...)
+		}
+		if len(commands) == 0 {
 			fmt.Fprintln(os.Stderr, "Error: command required")
 			flag.Usage()
 			os.Exit(1)
@@ -90,16 +292,96 @@ func main() {
 			}
 			autoStart = &recording.AutoStartRequest{Path: p}
 		}
-		command := strings.Join(args, " ")
-		model = tui.New(tui.Config{
-			Command:        command,
-			Interval:       *interval,
-			DiffEnabled:    !*disableDiff,
-			ShowStatus:     !*hideStatus,
-			NotifyOnChange: *enableNotify,
-			AutoStart:      autoStart,
-			MaxHistory:     *historyLimit,
-		})
+		command, argv := commandLine(commands[0], execArgv)
+		// Without a terminal there is nothing to draw the TUI on; a scripted wait on an exit
+		// condition (CI, `until wch -g ...`) runs the same loop headless, quietly. -headless
+		// asks for that loop explicitly and prints the change log.
+		runsHeadless := *headlessMode || (exit.Enabled() && !isTerminal(os.Stdout))
+		if len(commands) > 1 && (runsHeadless || *eventsFormat != "") {
+			fmt.Fprintln(os.Stderr, "Error: -headless, -events and exit conditions without a terminal take a single command")
+			os.Exit(1)
+		}
+		if *eventsFormat != "" {
+			if *eventsOut == "" {
+				if !runsHeadless {
+					fmt.Fprintln(os.Stderr, "Error: -events needs -events-out while the TUI owns stdout")
+					os.Exit(1)
+				}
+				sink = events.NewJSON(os.Stdout, align)
+			} else {
+				f, err := os.Create(*eventsOut)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				sink, sinkFile = events.NewJSON(f, align), f
+			}
+		}
+		if runsHeadless {
+			var trig trigger.Source
+			if len(onChange) > 0 {
+				trig = watchFiles(onChange)
+			}
+			cfg := headless.Config{
+				Interval: *interval,
+				Align:    align,
+				Exit:     exit,
+				Events:   sink,
+				Schedule: newSched(),
+				Precise:  *precise,
+				Overlap:  overlap,
+				Trigger:  trig,
+			}
+			// The change log and an event stream on stdout would interleave two formats;
+			// the machine-readable one wins.
+			if *headlessMode && (sink == nil || sinkFile != nil) {
+				cfg.Log = os.Stdout
+			}
+			r := runner.New(command)
+			if argv != nil {
+				r = runner.NewArgv(argv)
+			}
+			r.Shell = *shell
+			r.PTY = *usePTY
+			r.Timeout = *timeout
+			if *stream {
+				sig := trigger.NewSignal("output", trigger.Debounce)
+				r.Stream, r.StreamLines, r.OnOutput = true, *streamLines, sig.Fire
+				cfg.Trigger = sig
+			}
+			hist := historyOptions{limit: *historyLimit, bytes: int64(historyBytes), spool: int64(spool)}
+			code := runHeadless(command, argv, r, hist, autoStart, *compress, cfg)
+			r.Close()
+			os.Exit(max(code, closeEvents(sink, sinkFile)))
+		}
+		base := tui.Config{
+			Interval:        *interval,
+			DiffEnabled:     !*disableDiff,
+			ShowGhosts:      *showGhosts,
+			ShowDeltas:      *showDeltas,
+			BaselineFirst:   *baseline == "first",
+			Align:           align,
+			ShowStatus:      !*hideStatus,
+			NotifyOnChange:  *enableNotify,
+			Shell:           *shell,
+			PTY:             *usePTY,
+			Timeout:         *timeout,
+			Stream:          *stream,
+			StreamLines:     *streamLines,
+			MaxHistory:      *historyLimit,
+			MaxHistoryBytes: int64(historyBytes),
+			Spool:           int64(spool),
+			Compress:        *compress,
+			Exit:            exit,
+			Events:          sink,
+			Precise:         *precise,
+			Overlap:         overlap,
+		}
+		if len(commands) > 1 {
+			model = newPanes(base, newSched, commands, execArgv, onChange, autoStart)
+		} else {
+			model = tui.New(paneConfig(base, newSched, command, argv, onChange, autoStart))
+		}
 	}
 
 	p := tea.NewProgram(model)
@@ -110,15 +392,290 @@ func main() {
 	// error — so an active recording is closed before we exit. Cleanup is idempotent
 	// (no-op when no recording is active).
 	var cleanupErr error
-	if m, ok := finalModel.(tui.Model); ok {
+	var reason exitcond.Reason
+	if m, ok := finalModel.(interface {
+		Cleanup() error
+		ExitReason() exitcond.Reason
+	}); ok {
 		cleanupErr = m.Cleanup()
+		reason = m.ExitReason()
 	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
+	// A failed close is an error, but a condition that fired still reports its own status.
+	code := reason.ExitCode()
 	if cleanupErr != nil {
 		fmt.Fprintf(os.Stderr, "wch: recording: %v\n", cleanupErr)
+		code = max(code, 1)
+	}
+	os.Exit(max(code, closeEvents(sink, sinkFile)))
+}
+
+// splitCommands splits the arguments after a leading "--" into commands at each further
+// "--": "a -- b c" is two commands. Empty ones ("a -- --") are dropped.
+func splitCommands(args []string) [][]string {
+	var commands [][]string
+	var cur []string
+	for _, a := range append(args, "--") {
+		if a != "--" {
+			cur = append(cur, a)
+			continue
+		}
+		if len(cur) > 0 {
+			commands = append(commands, cur)
+		}
+		cur = nil
+	}
+	return commands
+}
+
+// readCommands reads a -commands file: one command per line, blank lines and lines starting
+// with "#" skipped. A line is one shell command, or under -x its arguments, split and
+// unquoted as a shell would (runner.SplitWords) but not expanded.
+func readCommands(path string, execArgv bool) ([][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var commands [][]string
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !execArgv {
+			commands = append(commands, []string{line})
+			continue
+		}
+		argv, err := runner.SplitWords(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n+1, err)
+		}
+		commands = append(commands, argv)
+	}
+	return commands, nil
+}
+
+// commandLine returns the command's display (and shell) form, and its argv under -x.
+func commandLine(args []string, execArgv bool) (string, []string) {
+	if execArgv {
+		return runner.Quote(args), args
+	}
+	return strings.Join(args, " "), nil
+}
+
+// watchFiles starts an -on-change watcher, exiting on failure.
+func watchFiles(paths []string) trigger.Source {
+	files, err := trigger.WatchFiles(paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -on-change: %v\n", err)
 		os.Exit(1)
 	}
+	return files
+}
+
+// paneConfig completes base for one command: its own scheduler from newSched (-backoff's
+// follows its pane's results), its own watcher under -on-change (a trigger's events go to a
+// single reader) and the recording request.
+func paneConfig(base tui.Config, newSched func() schedule.Scheduler, command string, argv []string, onChange []string, autoStart *recording.AutoStartRequest) tui.Config {
+	cfg := base
+	cfg.Command, cfg.Argv = command, argv
+	cfg.Schedule = newSched()
+	cfg.AutoStart = autoStart
+	if len(onChange) > 0 {
+		cfg.Trigger = watchFiles(onChange)
+	}
+	return cfg
+}
+
+// newPanes builds the model for several commands, one pane each. Under -w they record into
+// one multi-stream recording.
+func newPanes(base tui.Config, newSched func() schedule.Scheduler, commands [][]string, execArgv bool, onChange []string, autoStart *recording.AutoStartRequest) tui.Multi {
+	lines := make([]string, len(commands))
+	argvs := make([][]string, len(commands))
+	streams := make([]recording.Stream, len(commands))
+	for i, c := range commands {
+		lines[i], argvs[i] = commandLine(c, execArgv)
+		streams[i] = recording.Stream{Command: lines[i], Argv: argvs[i], Interval: base.Interval.String()}
+	}
+	var mux *recording.Multiplex
+	if autoStart != nil {
+		var err error
+		if mux, err = recording.NewMultiplex(autoStart.Path, streams, base.Compress); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	panes := make([]tui.Model, len(commands))
+	for i := range commands {
+		var req *recording.AutoStartRequest
+		if mux != nil {
+			req = &recording.AutoStartRequest{Path: autoStart.Path, Recorder: mux.Recorder(i)}
+		}
+		panes[i] = tui.New(paneConfig(base, newSched, lines[i], argvs[i], onChange, req))
+	}
+	return tui.NewMulti(panes)
+}
+
+// closeEvents closes the event stream's file (if it has its own) and reports any write error
+// the sink latched. Returns 1 on error, else 0; callers combine it with max so a fired exit
+// condition's status still wins over an event-stream failure.
+func closeEvents(sink *events.Sink, f *os.File) int {
+	err := sink.Err()
+	if f != nil {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "wch: events: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runHeadless runs the watch without the TUI until an exit condition fires or the process
+// is interrupted, and returns the process exit status.
+func runHeadless(command string, argv []string, r *runner.Runner, hist historyOptions, autoStart *recording.AutoStartRequest, compress bool, cfg headless.Config) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s := session.NewSession(command, cfg.Interval)
+	hist.apply(s)
+	defer func() { _ = s.History.Close() }()
+	s.Argv = argv
+	if len(cfg.Align.Ignore) > 0 {
+		s.Mask = cfg.Align.Masked
+	}
+	flow := recording.New(s)
+	flow.Compress = compress
+	if autoStart != nil {
+		if err := flow.Start(autoStart.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	}
+	reason, err := headless.Run(ctx, r, s, cfg)
+	if cfg.Trigger != nil {
+		_ = cfg.Trigger.Close()
+	}
+	if stopErr := flow.Stop(); stopErr != nil {
+		fmt.Fprintf(os.Stderr, "wch: recording: %v\n", stopErr)
+		if err == nil {
+			return max(reason.ExitCode(), 1) // a condition that fired still reports its own status
+		}
+		return 1
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return 130 // interrupted, as a shell reports SIGINT
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "wch: %v\n", err)
+		return 1
+	}
+	return reason.ExitCode()
+}
+
+// historyOptions are the history limits and storage a headless session gets (-l,
+// -history-bytes, -spool); the TUI takes them through tui.Config.
+type historyOptions struct {
+	limit int
+	bytes int64
+	spool int64
+}
+
+func (o historyOptions) apply(s *session.Session) {
+	s.MaxHistory, s.MaxHistoryBytes = o.limit, o.bytes
+	if o.spool > 0 {
+		s.History = session.NewSpoolHistory("", o.spool)
+	}
+}
+
+// mustCompile compiles an exit-condition regexp flag value; empty means "not set".
+func mustCompile(name, expr string) *regexp.Regexp {
+	if expr == "" {
+		return nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", name, err)
+		os.Exit(1)
+	}
+	return re
+}
+
+// isTerminal reports whether f is attached to a character device (a TTY).
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// patternList is a repeatable regexp flag (-ignore).
+type patternList []*regexp.Regexp
+
+func (p *patternList) String() string {
+	exprs := make([]string, len(*p))
+	for i, re := range *p {
+		exprs[i] = re.String()
+	}
+	return strings.Join(exprs, ", ")
+}
+
+func (p *patternList) Set(expr string) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	*p = append(*p, re)
+	return nil
+}
+
+// pathList is a repeatable path flag (-on-change).
+type pathList []string
+
+func (p *pathList) String() string { return strings.Join(*p, ", ") }
+
+func (p *pathList) Set(path string) error {
+	*p = append(*p, path)
+	return nil
+}
+
+// byteSize is a size flag: bytes, or a number with a K, M, G or T suffix in powers of 1024
+// ("500M", "1.5G"; a trailing "B" or "iB" is allowed).
+type byteSize int64
+
+// String uses the largest suffix the size is a whole multiple of, so defaults read as set.
+func (b *byteSize) String() string {
+	n := int64(*b)
+	for i := len("KMGT"); i > 0 && n != 0; i-- {
+		if unit := int64(1) << (10 * i); n%unit == 0 {
+			return strconv.FormatInt(n/unit, 10) + "KMGT"[i-1:i]
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+func (b *byteSize) Set(v string) error {
+	num := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(v)), "B"), "I")
+	shift := 0
+	if num != "" {
+		if i := strings.IndexByte("KMGT", num[len(num)-1]); i >= 0 {
+			num, shift = num[:len(num)-1], 10*(i+1)
+		}
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil || f < 0 {
+		return fmt.Errorf("invalid size %q (want e.g. 500M or 2G)", v)
+	}
+	*b = byteSize(f * float64(int64(1)<<shift))
+	return nil
+}
+
+// flagSet reports whether the named flag was given on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
 }
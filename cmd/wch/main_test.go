@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivoronin/wch/internal/schedule"
+	"github.com/ivoronin/wch/internal/tui"
+)
+
+// Every pane gets a scheduler of its own, so one pane's results cannot move another's
+// -backoff interval.
+func TestPaneConfigSchedulerPerPane(t *testing.T) {
+	newSched := func() schedule.Scheduler { return schedule.NewBackoff(time.Second, time.Minute) }
+	base := tui.Config{Interval: time.Second}
+	a := paneConfig(base, newSched, "a", nil, nil, nil)
+	b := paneConfig(base, newSched, "b", nil, nil, nil)
+	if a.Schedule == nil || a.Schedule == b.Schedule {
+		t.Fatalf("panes share scheduler %p", a.Schedule)
+	}
+	a.Schedule.(*schedule.Backoff).Observe(true, false)
+	if got := b.Schedule.(*schedule.Backoff).Interval(); got != time.Second {
+		t.Errorf("pane b interval %v after pane a failed, want 1s", got)
+	}
+}
+
+// Under -x a -commands line keeps its quoted arguments whole.
+func TestReadCommandsExecArgv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commands")
+	if err := os.WriteFile(path, []byte("# panes\ngrep \"a b\" f\n\nls 'my dir'\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readCommands(path, true)
+	want := [][]string{{"grep", "a b", "f"}, {"ls", "my dir"}}
+	if err != nil || !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("readCommands=%q, %v want %q", got, err, want)
+	}
+	if err := os.WriteFile(path, []byte("ls\ngrep 'a b f\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCommands(path, true); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("unterminated quote: err=%v, want it reported at line 2", err)
+	}
+}
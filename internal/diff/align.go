@@ -10,20 +10,29 @@
 //     whose AGE ticked still pairs with its old self instead of looking like a delete+insert.
 //     This is patience diff with a similarity gap-filler; anchoring keeps it linear on large
 //     output.
-//   - Structured diff (Lines): each new-output line tagged Equal/Changed/Added, with a
-//     word-level Span breakdown for changed lines. The caller styles the Changed spans.
+//   - Move detection: rows that changed position (e.g. `kubectl top --sort-by`, `ps
+//     --sort`) are paired by identity token, the leftmost field unique to the row on both
+//     sides, and reported as moved rather than as a delete plus an add, or as every row in
+//     between changing.
+//   - Structured diff (Lines): each new-output line tagged Equal/Changed/Moved/Added, with
+//     a word-level Span breakdown for changed (and changed-and-moved) lines. The caller
+//     styles the Changed spans. LinesWithDeleted also reports removed old lines (Deleted) at
+//     their old position.
+//   - Table mode: when both snapshots are whitespace-aligned tables with the same header,
+//     rows are compared cell by cell instead of word by word. A cell is the unit of change,
+//     and a row realigned only because a column got wider is Equal.
 //   - Position mapping (MapLine): where an old line moved to, for preserving a scroll or
 //     cursor position across refreshes.
 //
-// The design is order-preserving: it does not track moves, so reordered output (e.g.
-// `kubectl top --sort-by`) shows moved rows as changed.
-//
 // Internally the concerns form a one-directional chain:
 //
-//	{lcs, similarity, token} -> align -> { mapline, worddiff } -> diff
+//	{lcs, similarity, token} -> align -> move -> { mapline, worddiff, table } -> diff
 package diff
 
-import "strings"
+import (
+	"regexp"
+	"strings"
+)
 
 const (
 	// simThreshold is the minimum token-overlap (Jaccard) score for two lines to be treated
@@ -51,14 +60,30 @@ type op struct {
 	newIdx int // set for opMatch and opInsert, else -1
 }
 
-// Alignment is the order-preserving correspondence between the lines of two outputs, built
-// by anchoring on unchanged unique lines and similarity-aligning the gaps between them. It
-// answers where a line moved (MapLine) and what changed per line (Lines).
+// Alignment is the correspondence between the lines of two outputs, built by anchoring on
+// unchanged unique lines and similarity-aligning the gaps between them. The ops are
+// order-preserving; rows that changed position are paired by identity on the side (movedTo,
+// movedFrom). It answers where a line moved (MapLine) and what changed per line (Lines).
 type Alignment struct {
-	ops      []op
-	oldLines []string
-	newLines []string
-	coarse   bool
+	ops       []op
+	oldLines  []string
+	newLines  []string
+	coarse    bool
+	movedTo   map[int]int // old index -> new index of a moved row (see detectMoves)
+	movedFrom map[int]int // the inverse of movedTo
+	oldTable  *table      // column layouts when both snapshots are the same table, else nil
+	newTable  *table
+	oldKeys   []string // per-line Options.Key values; nil when no key applies
+	newKeys   []string
+	rawOld    []string // the unmasked lines when Options.Ignore applies (old/newLines are masked)
+	rawNew    []string
+	newToRaw  [][]int // per new line, masked rune offset -> raw rune offset (see maskLine)
+}
+
+// Options tune Align. The zero value infers row identity from the output.
+type Options struct {
+	Key    Key              // columns that identify a row; pairing is exact on a key match
+	Ignore []*regexp.Regexp // volatile substrings masked out before comparing (see Masked)
 }
 
 func splitLines(s string) []string {
@@ -68,9 +93,41 @@ func splitLines(s string) []string {
 	return strings.Split(s, "\n")
 }
 
-// Align builds a similarity-based, order-preserving alignment of oldText to newText.
+// Align builds a similarity-based alignment of oldText to newText, with reordered rows
+// paired by identity as moves.
 func Align(oldText, newText string) Alignment {
+	return Options{}.Align(oldText, newText)
+}
+
+// Align is the package-level Align under o. With a Key, rows whose keys match pair exactly,
+// rows whose keys differ never pair, and similarity decides only for rows without a key
+// (blank key cells, or a snapshot whose header lacks a named column).
+//
+// With Ignore patterns the comparison runs on masked lines, so rows differing only inside
+// ignored matches are Equal; the Lines reported still carry the raw text.
+func (o Options) Align(oldText, newText string) Alignment {
 	a := Alignment{oldLines: splitLines(oldText), newLines: splitLines(newText)}
+	if len(o.Ignore) > 0 {
+		a.rawOld, a.rawNew = a.oldLines, a.newLines
+		a.oldLines, a.newLines = make([]string, len(a.rawOld)), make([]string, len(a.rawNew))
+		a.newToRaw = make([][]int, len(a.rawNew))
+		for i, l := range a.rawOld {
+			a.oldLines[i], _ = o.maskLine(l)
+		}
+		for j, l := range a.rawNew {
+			a.newLines[j], a.newToRaw[j] = o.maskLine(l)
+		}
+	}
+	ot, nt := detectTable(a.oldLines), detectTable(a.newLines)
+	if sameColumns(ot, nt) {
+		a.oldTable, a.newTable = ot, nt
+	}
+	if !o.Key.IsZero() {
+		a.oldKeys, a.newKeys = o.Key.values(a.oldLines, ot), o.Key.values(a.newLines, nt)
+		if a.oldKeys == nil || a.newKeys == nil {
+			a.oldKeys, a.newKeys = nil, nil
+		}
+	}
 	n, m := len(a.oldLines), len(a.newLines)
 
 	// Peel byte-identical common prefix/suffix (cheap; also handles duplicate runs).
@@ -90,6 +147,7 @@ func Align(oldText, newText string) Alignment {
 	for k := range s {
 		a.ops = append(a.ops, op{opMatch, n - s + k, m - s + k})
 	}
+	a.detectMoves()
 	return a
 }
 
@@ -98,9 +156,10 @@ type anchor struct{ oi, ni int }
 
 // findAnchors returns, increasing on both sides, the lines that are byte-identical and
 // appear exactly once in each range. These are unambiguous correspondences: an unchanged row
-// pins to its twin no matter how large or scattered the surrounding diff is. The greedy
-// increasing scan is optimal for stable-ordered output (insert/delete preserve the relative
-// order of survivors); reordered lines simply do not anchor.
+// pins to its twin no matter how large or scattered the surrounding diff is. When rows were
+// reordered the candidates cross; the longest non-crossing subset anchors and the rest are
+// left to the gaps (and to detectMoves), so a single row moving to the top costs one move
+// rather than unanchoring everything it jumped over.
 func (a *Alignment) findAnchors(oLo, oHi, nLo, nHi int) []anchor {
 	oCount := make(map[string]int, oHi-oLo)
 	for i := oLo; i < oHi; i++ {
@@ -115,17 +174,20 @@ func (a *Alignment) findAnchors(oLo, oHi, nLo, nHi int) []anchor {
 			nFirst[nl] = j
 		}
 	}
-	var anchors []anchor
-	lastNi := nLo - 1
+	var cands []anchor
+	var seq []int
 	for i := oLo; i < oHi; i++ {
 		line := a.oldLines[i]
 		if oCount[line] == 1 && nCount[line] == 1 {
-			if ni := nFirst[line]; ni > lastNi {
-				anchors = append(anchors, anchor{i, ni})
-				lastNi = ni
-			}
+			cands = append(cands, anchor{i, nFirst[line]})
+			seq = append(seq, nFirst[line])
 		}
 	}
+	keep := longestIncreasing(seq)
+	anchors := make([]anchor, len(keep))
+	for k, c := range keep {
+		anchors[k] = cands[c]
+	}
 	return anchors
 }
 
@@ -180,6 +242,14 @@ func (a *Alignment) alignGap(oLo, oHi, nLo, nHi int) []op {
 		newSets[j] = tokenSet(a.newLines[nLo+j])
 	}
 	pairs := lcs(r, c, func(i, j int) float64 {
+		if a.oldKeys != nil {
+			if ok, nk := a.oldKeys[oLo+i], a.newKeys[nLo+j]; ok != "" && nk != "" {
+				if ok == nk {
+					return 1 + jaccard(oldSets[i], newSets[j]) // a key match outweighs any look-alike
+				}
+				return 0
+			}
+		}
 		if s := jaccard(oldSets[i], newSets[j]); s >= simThreshold {
 			return s // weight by similarity so an exact twin (1) beats a look-alike
 		}
@@ -1,6 +1,7 @@
 package diff
 
 import (
+	"slices"
 	"strings"
 	"testing"
 )
@@ -186,3 +187,172 @@ func TestLinesCountEqualsNewLines(t *testing.T) {
 		t.Errorf("Lines()=%d want %d", len(lines), want)
 	}
 }
+
+// kubectl top --sort-by=cpu: two pods swap places as their CPU changes. The swapped rows are
+// moved (keeping their word spans), the untouched ones stay equal, nothing reads as added.
+func TestLinesReorderIsMove(t *testing.T) {
+	old := text(
+		"NAME   CPU(cores) MEMORY(bytes)",
+		"pod-a  90m        100Mi",
+		"pod-b  40m        120Mi",
+		"pod-c  10m        80Mi",
+	)
+	updated := text(
+		"NAME   CPU(cores) MEMORY(bytes)",
+		"pod-b  95m        120Mi",
+		"pod-a  60m        100Mi",
+		"pod-c  10m        80Mi",
+	)
+	lines := Align(old, updated).Lines()
+	want := []struct {
+		kind LineKind
+		old  int
+	}{{LineEqual, 0}, {LineMoved, 2}, {LineChanged, 1}, {LineEqual, 3}}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines want %d", len(lines), len(want))
+	}
+	for i, w := range want {
+		if lines[i].Kind != w.kind || lines[i].OldIndex != w.old {
+			t.Errorf("line %d kind=%d old=%d want kind=%d old=%d", i, lines[i].Kind, lines[i].OldIndex, w.kind, w.old)
+		}
+	}
+	if got := changedSpans(lines[1].Spans); len(got) != 1 || got[0] != "95m" {
+		t.Errorf("moved row changed spans=%v want [95m]", got)
+	}
+}
+
+// A row jumping from the bottom to the top is one move; the rows it jumped over stay equal
+// and a scroll anchor on it follows it.
+func TestLinesSingleRowMovesToTop(t *testing.T) {
+	old := text("h", "a 1", "b 2", "c 3", "d 4")
+	updated := text("h", "d 4", "a 1", "b 2", "c 3")
+	a := Align(old, updated)
+	lines := a.Lines()
+	for i, ln := range lines {
+		want := LineEqual
+		if i == 1 {
+			want = LineMoved
+		}
+		if ln.Kind != want {
+			t.Errorf("line %d (%q) kind=%d want %d", i, ln.Text, ln.Kind, want)
+		}
+	}
+	if lines[1].Spans != nil {
+		t.Errorf("unchanged moved row has spans %+v", lines[1].Spans)
+	}
+	if got := a.MapLine(4); got != 1 {
+		t.Errorf("MapLine(4)=%d want 1", got)
+	}
+}
+
+// Rows alike enough to pass the similarity threshold but with different identities are not
+// paired with each other: each is paired with its own old self.
+func TestLinesIdentityBeatsSimilarity(t *testing.T) {
+	old := text("pod-a 5m 10Mi", "pod-b 7m 10Mi")
+	updated := text("pod-b 7m 10Mi", "pod-c 5m 10Mi")
+	for _, ln := range Align(old, updated).LinesWithDeleted() {
+		if ln.Kind == LineChanged {
+			t.Errorf("%q paired as changed with old line %d, want no cross-identity pairing", ln.Text, ln.OldIndex)
+		}
+	}
+}
+
+// ps aux: USER repeats on every row, so the PID column is the identity.
+func TestIdentitiesSkipRepeatedFields(t *testing.T) {
+	keys := identities([]string{"root 1 0.0 init", "root 42 1.5 sshd", "root 42x 1.5 sshd"}, -1)
+	want := []string{"1", "42", "42x"}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Errorf("identity %d=%q want %q", i, keys[i], want[i])
+		}
+	}
+}
+
+// AGE is the leftmost unique column, and one row's new age is another's old one. The NAME
+// column, unchanged across the tick, is the identity instead, so every row is an edit in
+// place rather than a delete, an add and a move.
+func TestIdentityPrefersStableColumn(t *testing.T) {
+	old := text("Running 5m web-1", "Running 6m web-2", "Running 9m web-3")
+	updated := text("Running 6m web-1", "Running 7m web-2", "Running 9m web-3")
+	lines := Align(old, updated).LinesWithDeleted()
+	if len(lines) != 3 {
+		t.Fatalf("%d lines, want 3 (no deletes): %+v", len(lines), lines)
+	}
+	for i, ln := range lines {
+		want := LineChanged
+		if i == 2 {
+			want = LineEqual
+		}
+		if ln.Kind != want || ln.OldIndex != i {
+			t.Errorf("line %d: kind=%d old=%d, want kind=%d old=%d", i, ln.Kind, ln.OldIndex, want, i)
+		}
+	}
+}
+
+func TestLongestIncreasing(t *testing.T) {
+	seq := []int{3, 0, 1, 5, 2, 4}
+	got := longestIncreasing(seq)
+	var vals []int
+	for _, p := range got {
+		vals = append(vals, seq[p])
+	}
+	if want := []int{0, 1, 2, 4}; !slices.Equal(vals, want) {
+		t.Errorf("values=%v want %v", vals, want)
+	}
+	if got := longestIncreasing(nil); len(got) != 0 {
+		t.Errorf("empty: %v", got)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	k, err := ParseKey("2,NAME, NAMESPACE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(k.Columns, []int{2}) || !slices.Equal(k.Names, []string{"NAME", "NAMESPACE"}) {
+		t.Errorf("key=%+v", k)
+	}
+	for _, bad := range []string{"", "0", "NAME,,AGE"} {
+		if _, err := ParseKey(bad); err == nil {
+			t.Errorf("ParseKey(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+// The leading CPU column is unique on every row, so the inferred identity is a volatile
+// value and the rows share too few tokens to pair by similarity. A key on NAME pairs them.
+func TestKeyPairsRowsSimilarityMisses(t *testing.T) {
+	old := text("CPU  NAME", "90m  pod-a", "40m  pod-b")
+	updated := text("CPU  NAME", "95m  pod-b", "60m  pod-a")
+	for _, spec := range []string{"2", "name"} {
+		key, err := ParseKey(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := Options{Key: key}.Align(old, updated).Lines()
+		want := []struct {
+			kind LineKind
+			old  int
+		}{{LineEqual, 0}, {LineMoved, 2}, {LineChanged, 1}}
+		for i, w := range want {
+			if lines[i].Kind != w.kind || lines[i].OldIndex != w.old {
+				t.Errorf("key %s: line %d kind=%d old=%d want kind=%d old=%d", spec, i, lines[i].Kind, lines[i].OldIndex, w.kind, w.old)
+			}
+		}
+	}
+}
+
+// Rows with different keys never pair, however alike; a named column missing from the
+// header falls back to similarity.
+func TestKeyMismatchNeverPairs(t *testing.T) {
+	old := text("NAME   STATUS    NODE     AGE", "job-a  Running   node-1   5m")
+	updated := text("NAME   STATUS    NODE     AGE", "job-b  Running   node-1   5m")
+	lines := Options{Key: Key{Names: []string{"NAME"}}}.Align(old, updated).Lines()
+	if lines[1].Kind != LineAdded {
+		t.Errorf("keyed: kind=%d want LineAdded", lines[1].Kind)
+	}
+	lines = Options{Key: Key{Names: []string{"POD"}}}.Align(old, updated).Lines()
+	if lines[1].Kind != LineChanged {
+		t.Errorf("missing key column: kind=%d want LineChanged (similarity)", lines[1].Kind)
+	}
+}
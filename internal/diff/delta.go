@@ -0,0 +1,222 @@
+package diff
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Deltas returns, per span of ln, the signed difference its field shows against the old
+// one, to be annotated after it: a plain number ("+17"), a size ("+2Mi"), or a duration
+// ("+1m30s"). A field is a run of spans without whitespace; it has a delta when it holds a
+// Changed span and every Changed span in it was paired with old text (Span.Old), so the old
+// field is the new one with that text put back, and both parse as quantities of the same
+// kind. The delta goes on the field's last span, and "" on every other. A table cell holding
+// spaces ("Running 5m40s") is word-diffed against its old cell, and its fields' deltas go on
+// it together ("+2, +37s"). Deltas returns nil for a line without any.
+func Deltas(ln Line) []string {
+	if ln.Kind != LineChanged && ln.Kind != LineMoved {
+		return nil
+	}
+	return spanDeltas(ln.Spans)
+}
+
+// spanDeltas implements Deltas.
+func spanDeltas(spans []Span) []string {
+	var deltas []string
+	set := func(i int, d string) {
+		if deltas == nil {
+			deltas = make([]string, len(spans))
+		}
+		deltas[i] = d
+	}
+	var newField, oldField strings.Builder
+	last, changed, paired := -1, false, true
+	flush := func() {
+		if changed && paired {
+			if d, ok := quantityDelta(oldField.String(), newField.String()); ok {
+				set(last, d)
+			}
+		}
+		newField.Reset()
+		oldField.Reset()
+		last, changed, paired = -1, false, true
+	}
+	for i, sp := range spans {
+		if strings.TrimSpace(sp.Text) == "" {
+			flush()
+			continue
+		}
+		if sp.Changed && sp.Old != "" && strings.ContainsFunc(sp.Text, unicode.IsSpace) {
+			flush()
+			var inner []string
+			for _, d := range spanDeltas(WordDiff(sp.Old, sp.Text)) {
+				if d != "" {
+					inner = append(inner, d)
+				}
+			}
+			if len(inner) > 0 {
+				set(i, strings.Join(inner, ", "))
+			}
+			continue
+		}
+		newField.WriteString(sp.Text)
+		if sp.Changed {
+			changed, paired = true, paired && sp.Old != ""
+			oldField.WriteString(sp.Old)
+		} else {
+			oldField.WriteString(sp.Text)
+		}
+		last = i
+	}
+	flush()
+	return deltas
+}
+
+type quantityKind uint8
+
+const (
+	kindNumber   quantityKind = iota // a number, maybe with a suffix kept as written ("17", "46%", "250m")
+	kindSize                         // bytes, with a binary or decimal multiplier ("12Mi", "1.5G")
+	kindDuration                     // seconds ("45s", "5m3s", "2d3h")
+)
+
+// quantity is a parsed numeric field. v is in base units (the number itself, bytes, or
+// seconds); scale is base units per unit of the value as written, so a delta can be shown
+// in the new value's unit.
+type quantity struct {
+	kind  quantityKind
+	v     float64
+	unit  string
+	scale float64
+	prec  int // decimal places as written
+}
+
+var (
+	numberRe     = regexp.MustCompile(`^([-+]?\d+(?:\.\d+)?)(%|[a-zA-Z]*)$`)
+	sizeRe       = regexp.MustCompile(`^(\d+(?:\.\d+)?)([KMGTPE]i?B?|kB?|B)$`)
+	durationRe   = regexp.MustCompile(`^(?:\d+(?:\.\d+)?(?:d|h|m|s|ms|us|µs|ns))+$`)
+	durationPart = regexp.MustCompile(`(\d+(?:\.\d+)?)(d|h|ms|us|µs|ns|m|s)`)
+)
+
+// sizeScale maps a size multiplier's letter to its decimal and binary ("Ki") scales.
+var sizeScale = map[byte][2]float64{
+	'B': {1, 1},
+	'k': {1e3, 1 << 10}, 'K': {1e3, 1 << 10},
+	'M': {1e6, 1 << 20}, 'G': {1e9, 1 << 30}, 'T': {1e12, 1 << 40}, 'P': {1e15, 1 << 50}, 'E': {1e18, 1 << 60},
+}
+
+var durationScale = map[string]float64{
+	"d": 86400, "h": 3600, "m": 60, "s": 1, "ms": 1e-3, "us": 1e-6, "µs": 1e-6, "ns": 1e-9,
+}
+
+// fieldPunct is stripped from both ends of a field before parsing, so "120," in
+// `(estab 120, closed 30)` reads as 120.
+const fieldPunct = "()[]{},;:"
+
+// parseQuantity parses a field as a duration, a size, or a number, in that order. A single
+// minute component ("5m") is ambiguous with CPU millicores and stays a number with suffix
+// "m": the delta is the same either way, and it never converts across units.
+func parseQuantity(s string) (quantity, bool) {
+	s = strings.Trim(s, fieldPunct)
+	if durationRe.MatchString(s) {
+		parts := durationPart.FindAllStringSubmatch(s, -1)
+		if len(parts) > 1 || parts[0][2] != "m" {
+			var v float64
+			for _, p := range parts {
+				n, _ := strconv.ParseFloat(p[1], 64)
+				v += n * durationScale[p[2]]
+			}
+			return quantity{kind: kindDuration, v: v, unit: "s", scale: 1}, true
+		}
+	}
+	if m := sizeRe.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.ParseFloat(m[1], 64)
+		unit := m[2]
+		scale := sizeScale[unit[0]][0]
+		if len(unit) > 1 && unit[1] == 'i' {
+			scale = sizeScale[unit[0]][1]
+		}
+		return quantity{kind: kindSize, v: n * scale, unit: unit, scale: scale, prec: decimals(m[1])}, true
+	}
+	if m := numberRe.FindStringSubmatch(s); m != nil {
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return quantity{}, false
+		}
+		return quantity{kind: kindNumber, v: n, unit: m[2], scale: 1, prec: decimals(m[1])}, true
+	}
+	return quantity{}, false
+}
+
+func decimals(num string) int {
+	if i := strings.IndexByte(num, '.'); i >= 0 {
+		return len(num) - i - 1
+	}
+	return 0
+}
+
+// quantityDelta formats new - old when both fields are quantities of the same kind (and, for
+// plain numbers, the same suffix). A zero delta reports false.
+func quantityDelta(oldField, newField string) (string, bool) {
+	o, ok1 := parseQuantity(oldField)
+	n, ok2 := parseQuantity(newField)
+	if !ok1 || !ok2 || o.kind != n.kind || (n.kind == kindNumber && o.unit != n.unit) {
+		return "", false
+	}
+	d := (n.v - o.v) / n.scale // in the new value's unit
+	sign := "+"
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	if n.kind == kindDuration {
+		if d == 0 {
+			return "", false
+		}
+		return sign + formatDuration(d), true
+	}
+	prec := max(o.prec, n.prec)
+	if n.kind == kindSize && o.unit != n.unit {
+		prec = max(prec, 1) // a cross-unit size delta is rarely whole in the new unit
+	}
+	text := strconv.FormatFloat(d, 'f', prec, 64)
+	if n.kind == kindSize {
+		text = trimZeros(text)
+	}
+	if strings.Trim(text, "0.") == "" {
+		return "", false // rounds to zero as written
+	}
+	return sign + text + n.unit, true
+}
+
+func trimZeros(s string) string {
+	if strings.IndexByte(s, '.') < 0 {
+		return s
+	}
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// formatDuration renders seconds in the compact style of kubectl's AGE column ("2d3h",
+// "1m30s"), falling back to time.Duration's form below a second.
+func formatDuration(sec float64) string {
+	if sec < 1 {
+		return time.Duration(sec * float64(time.Second)).String()
+	}
+	total := int64(math.Round(sec))
+	var b strings.Builder
+	for _, u := range []struct {
+		name string
+		size int64
+	}{{"d", 86400}, {"h", 3600}, {"m", 60}, {"s", 1}} {
+		if n := total / u.size; n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, u.name)
+			total -= n * u.size
+		}
+	}
+	return b.String()
+}
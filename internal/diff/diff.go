@@ -7,47 +7,122 @@ const (
 	LineEqual   LineKind = iota // unchanged from the old snapshot
 	LineChanged                 // same row, some tokens changed (see Spans)
 	LineAdded                   // no counterpart in the old snapshot
+	LineDeleted                 // old row with no counterpart in the new snapshot (LinesWithDeleted only)
+	LineMoved                   // same row at a different position (Spans set if its tokens changed too)
 )
 
-// Line is the diff of one new-output line. For LineChanged, Spans is the word-level
-// breakdown (concatenating back to Text); for LineEqual and LineAdded it is nil. Deleted old
-// lines are not represented - the diff describes the new snapshot.
+// Line is the diff of one output line. For LineChanged, and for a LineMoved whose text also
+// changed, Spans is the word-level breakdown (concatenating back to Text); for the other
+// kinds it is nil. Text is the new-output line, except for LineDeleted where it is the
+// removed old-output line. Lines describes the new snapshot and omits deletions;
+// LinesWithDeleted also reports them.
 type Line struct {
 	Kind     LineKind
-	Text     string // the new-output line
-	OldIndex int    // matched old-output line index, or -1
+	Text     string // the new-output line (the old one for LineDeleted)
+	OldIndex int    // matched, moved-from, or (for LineDeleted) removed old-output line index, or -1
 	Spans    []Span
 }
 
 // Lines returns the structured diff of the new output: one Line per new-output line, in
 // order. Callers render it however they like (Equal plain, Added whole-line highlighted,
-// Changed with its Changed spans highlighted). Output length equals the number of new lines.
+// Changed with its Changed spans highlighted, Moved with a marker). Output length equals the
+// number of new lines.
 func (a Alignment) Lines() []Line {
+	return a.lines(false)
+}
+
+// LinesWithDeleted is Lines plus a LineDeleted entry for every old line that has no
+// counterpart in the new output, placed at its old position: after the new lines that
+// replaced its neighbourhood and before the next surviving row. Filtering out the
+// LineDeleted entries yields exactly Lines.
+func (a Alignment) LinesWithDeleted() []Line {
+	return a.lines(true)
+}
+
+// lines implements Lines and LinesWithDeleted. A moved row is reported at its new position
+// and its old one is skipped. A replace block's other deletes pair positionally with its
+// inserts (an in-place edit) unless their keys differ; the deletes left over when the block
+// ends are the unpaired ones, dropped or emitted as LineDeleted per withDeleted.
+func (a Alignment) lines(withDeleted bool) []Line {
 	lines := make([]Line, 0, len(a.newLines))
 	var pendDel []int // deletes awaiting an insert to pair with (an in-place replace)
 	di := 0
+	flush := func() {
+		if withDeleted {
+			for _, oi := range pendDel[di:] {
+				lines = append(lines, Line{Kind: LineDeleted, Text: a.oldLines[oi], OldIndex: oi})
+			}
+		}
+		pendDel, di = pendDel[:0], 0
+	}
 	for _, o := range a.ops {
 		switch o.kind {
 		case opDelete:
-			pendDel = append(pendDel, o.oldIdx)
-		case opMatch:
-			pendDel, di = pendDel[:0], 0 // unpaired deletes are dropped
-			nl := a.newLines[o.newIdx]
-			if a.oldLines[o.oldIdx] == nl {
-				lines = append(lines, Line{Kind: LineEqual, Text: nl, OldIndex: o.oldIdx})
-			} else {
-				lines = append(lines, Line{Kind: LineChanged, Text: nl, OldIndex: o.oldIdx, Spans: WordDiff(a.oldLines[o.oldIdx], nl)})
+			if _, moved := a.movedTo[o.oldIdx]; !moved {
+				pendDel = append(pendDel, o.oldIdx)
 			}
+		case opMatch:
+			flush()
+			lines = append(lines, a.pairedLine(o.oldIdx, o.newIdx, LineChanged))
 		case opInsert:
 			nl := a.newLines[o.newIdx]
-			if di < len(pendDel) {
+			if oldIdx, moved := a.movedFrom[o.newIdx]; moved {
+				ln := a.pairedLine(oldIdx, o.newIdx, LineMoved)
+				ln.Kind = LineMoved // moved even when its content is equal
+				lines = append(lines, ln)
+			} else if di < len(pendDel) && a.keysAllow(pendDel[di], o.newIdx) {
 				oldIdx := pendDel[di]
 				di++
-				lines = append(lines, Line{Kind: LineChanged, Text: nl, OldIndex: oldIdx, Spans: WordDiff(a.oldLines[oldIdx], nl)})
+				lines = append(lines, a.pairedLine(oldIdx, o.newIdx, LineChanged))
 			} else {
 				lines = append(lines, Line{Kind: LineAdded, Text: nl, OldIndex: -1})
 			}
 		}
 	}
+	flush()
+	if a.rawNew != nil {
+		a.unmask(lines)
+	}
 	return lines
 }
+
+// unmask puts the raw text back into lines computed on masked text (Options.Ignore).
+func (a Alignment) unmask(lines []Line) {
+	j := 0 // new-output index
+	for i, ln := range lines {
+		if ln.Kind == LineDeleted {
+			lines[i].Text = a.rawOld[ln.OldIndex]
+			continue
+		}
+		lines[i].Text = a.rawNew[j]
+		lines[i].Spans = unmaskSpans(ln.Spans, a.rawNew[j], a.newToRaw[j])
+		j++
+	}
+}
+
+// pairedLine diffs new line nj against its counterpart, old line oi: kind with the span
+// breakdown when they differ, else LineEqual. In table mode the comparison is per cell, so a
+// row that only changed padding is equal; otherwise any byte difference is a change.
+func (a Alignment) pairedLine(oi, nj int, kind LineKind) Line {
+	ol, nl := a.oldLines[oi], a.newLines[nj]
+	if ol == nl {
+		return Line{Kind: LineEqual, Text: nl, OldIndex: oi}
+	}
+	if a.newTable != nil {
+		if spans, changed := cellDiff(ol, a.oldTable, nl, a.newTable); changed {
+			return Line{Kind: kind, Text: nl, OldIndex: oi, Spans: spans}
+		}
+		return Line{Kind: LineEqual, Text: nl, OldIndex: oi}
+	}
+	return Line{Kind: kind, Text: nl, OldIndex: oi, Spans: WordDiff(ol, nl)}
+}
+
+// keysAllow reports whether old line oi and new line nj may pair positionally: always,
+// unless both have an Options.Key value and the values differ.
+func (a Alignment) keysAllow(oi, nj int) bool {
+	if a.oldKeys == nil {
+		return true
+	}
+	ok, nk := a.oldKeys[oi], a.newKeys[nj]
+	return ok == "" || nk == "" || ok == nk
+}
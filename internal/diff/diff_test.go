@@ -3,6 +3,7 @@ package diff
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 )
@@ -46,6 +47,7 @@ func TestTokenize(t *testing.T) {
 		{"a    b", []string{"a", "    ", "b"}},
 		{"pod-1 1/1 Running 5m", []string{"pod", "-", "1", " ", "1", "/", "1", " ", "Running", " ", "5m"}},
 		{"café", []string{"café"}},
+		{"load 1.25, 10.0.0.1.", []string{"load", " ", "1.25", ",", " ", "10.0.0.1", "."}},
 		{"", nil},
 	}
 	for _, c := range cases {
@@ -193,3 +195,218 @@ func TestRenderInsertWithVolatileFields(t *testing.T) {
 		}
 	}
 }
+
+// A removed row comes back as LineDeleted at its old position, carrying the old text.
+func TestLinesWithDeletedPlacesGhostAtOldPosition(t *testing.T) {
+	lines := Align(text("a", "b", "c"), text("a", "c")).LinesWithDeleted()
+	want := []struct {
+		kind LineKind
+		text string
+		old  int
+	}{{LineEqual, "a", 0}, {LineDeleted, "b", 1}, {LineEqual, "c", 2}}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines want %d: %+v", len(lines), len(want), lines)
+	}
+	for i, w := range want {
+		if lines[i].Kind != w.kind || lines[i].Text != w.text || lines[i].OldIndex != w.old {
+			t.Errorf("line %d = %+v want kind=%d text=%q old=%d", i, lines[i], w.kind, w.text, w.old)
+		}
+	}
+}
+
+// Trailing deletions (no following match to flush them) are reported too.
+func TestLinesWithDeletedTrailing(t *testing.T) {
+	lines := Align(text("a", "b", "c"), text("a")).LinesWithDeleted()
+	if len(lines) != 3 || lines[1].Kind != LineDeleted || lines[2].Kind != LineDeleted || lines[2].Text != "c" {
+		t.Errorf("got %+v want a, ghost b, ghost c", lines)
+	}
+}
+
+// Deletes paired with inserts are in-place changes, not deletions; dropping the LineDeleted
+// entries must give exactly Lines.
+func TestLinesWithDeletedFiltersToLines(t *testing.T) {
+	old := text("h", "pod-a 1/1 Running 5m", "pod-b 1/1 Running 5m", "gone x y z", "t")
+	updated := text("h", "pod-a 1/1 Running 6m", "new q r s", "t")
+	a := Align(old, updated)
+	var filtered []Line
+	for _, ln := range a.LinesWithDeleted() {
+		if ln.Kind != LineDeleted {
+			filtered = append(filtered, ln)
+		}
+	}
+	plain := a.Lines()
+	if len(filtered) != len(plain) {
+		t.Fatalf("filtered=%d lines, Lines=%d", len(filtered), len(plain))
+	}
+	for i := range plain {
+		if filtered[i].Kind != plain[i].Kind || filtered[i].Text != plain[i].Text {
+			t.Errorf("line %d: filtered %+v vs Lines %+v", i, filtered[i], plain[i])
+		}
+	}
+}
+
+// A longer pod name widens the NAME column and shifts every other column right. The old
+// rows' cells are unchanged, so they stay Equal; only the new row is reported.
+func TestTableColumnWideningIsNotAChange(t *testing.T) {
+	old := text(
+		"NAME    READY   STATUS    AGE",
+		"pod-1   1/1     Running   5m",
+		"pod-2   1/1     Running   5m",
+	)
+	updated := text(
+		"NAME              READY   STATUS    AGE",
+		"pod-1             1/1     Running   5m",
+		"pod-2             1/1     Running   5m",
+		"pod-with-a-long   0/1     Pending   1s",
+	)
+	lines := Align(old, updated).Lines()
+	want := []LineKind{LineEqual, LineEqual, LineEqual, LineAdded}
+	for i, k := range want {
+		if lines[i].Kind != k {
+			t.Errorf("line %d (%q) kind=%d want %d", i, lines[i].Text, lines[i].Kind, k)
+		}
+	}
+}
+
+// The cell is the unit of change: a multi-word STATUS cell lights up whole, the widened
+// padding around it does not.
+func TestTableCellIsUnitOfChange(t *testing.T) {
+	old := text(
+		"CONTAINER ID   IMAGE   STATUS         NAMES",
+		"3f4e8a1b2c9d   nginx   Up 5 minutes   web",
+	)
+	updated := text(
+		"CONTAINER ID   IMAGE   STATUS                  NAMES",
+		"3f4e8a1b2c9d   nginx   Up 5 minutes (healthy)   web",
+	)
+	lines := Align(old, updated).Lines()
+	if lines[0].Kind != LineEqual {
+		t.Errorf("header kind=%d want LineEqual", lines[0].Kind)
+	}
+	if lines[1].Kind != LineChanged {
+		t.Fatalf("row kind=%d want LineChanged", lines[1].Kind)
+	}
+	if got := changedSpans(lines[1].Spans); len(got) != 1 || got[0] != "Up 5 minutes (healthy)" {
+		t.Errorf("changed spans=%q want [Up 5 minutes (healthy)]", got)
+	}
+	var joined strings.Builder
+	for _, s := range lines[1].Spans {
+		joined.WriteString(s.Text)
+	}
+	if joined.String() != lines[1].Text {
+		t.Errorf("spans join to %q want %q", joined.String(), lines[1].Text)
+	}
+}
+
+func TestDetectTable(t *testing.T) {
+	df := []string{
+		"Filesystem      Size  Used Avail Use% Mounted on",
+		"udev            7.8G     0  7.8G   0% /dev",
+		"/dev/nvme0n1p2  468G  201G  244G  46% /",
+		"/dev/nvme0n1p1  511M  6.1M  505M   2% /boot",
+	}
+	tb := detectTable(df)
+	if tb == nil {
+		t.Fatal("df output not detected as a table")
+	}
+	if want := []string{"Filesystem", "Size", "Used", "Avail", "Use%", "Mounted on"}; !reflect.DeepEqual(tb.headers, want) {
+		t.Errorf("headers=%q want %q", tb.headers, want)
+	}
+
+	// Single-space separated, not aligned: the gutters would merge columns.
+	if tb := detectTable([]string{"NAME READY STATUS AGE", "pod-a 1/1 Running 9m", "pod-b 0/1 Pending 5m"}); tb != nil {
+		t.Errorf("unaligned output detected as table: %q", tb.headers)
+	}
+	if tb := detectTable([]string{"just one line"}); tb != nil {
+		t.Errorf("single line detected as table")
+	}
+}
+
+// An ignored clock changing is no change; the reported line carries the raw text, and a real
+// change elsewhere on the line is highlighted at its raw position.
+func TestIgnoreMasksVolatileText(t *testing.T) {
+	opts := Options{Ignore: []*regexp.Regexp{regexp.MustCompile(`\d+:\d\d:\d\d`)}}
+	old := text("Last updated: 9:59:59", "queue 5 jobs", "req 9:00:00 ok")
+	updated := text("Last updated: 10:00:00", "queue 5 jobs", "req 10:00:01 failed")
+	lines := opts.Align(old, updated).Lines()
+	if lines[0].Kind != LineEqual || lines[0].Text != "Last updated: 10:00:00" {
+		t.Errorf("clock line=%+v want Equal with raw text", lines[0])
+	}
+	if lines[2].Kind != LineChanged || lines[2].Text != "req 10:00:01 failed" {
+		t.Fatalf("line 2=%+v want Changed with raw text", lines[2])
+	}
+	if got := changedSpans(lines[2].Spans); !reflect.DeepEqual(got, []string{"failed"}) {
+		t.Errorf("changed spans=%q want [failed]", got)
+	}
+	var joined strings.Builder
+	for _, s := range lines[2].Spans {
+		joined.WriteString(s.Text)
+	}
+	if joined.String() != lines[2].Text {
+		t.Errorf("spans join to %q want %q", joined.String(), lines[2].Text)
+	}
+	if got := opts.Masked(old); got != text("Last updated: …", "queue 5 jobs", "req … ok") {
+		t.Errorf("Masked=%q", got)
+	}
+}
+
+func TestDeltas(t *testing.T) {
+	cases := []struct {
+		name, old, new string
+		ignore         string
+		want           []string // the non-empty deltas of the last line, in order
+	}{
+		{"counter", "IpInReceives   1515   0.0", "IpInReceives   1532   0.0", "", []string{"+17"}},
+		{"decimal", "load 1.50", "load 1.25", "", []string{"-0.25"}},
+		{"punctuated", "TCP: 40 (estab 120, closed 30)", "TCP: 41 (estab 118, closed 30)", "", []string{"+1", "-2"}},
+		{"size", "web  12Mi", "web  14Mi", "", []string{"+2Mi"}},
+		{"sizeAcrossUnits", "web  900Ki", "web  1.2Mi", "", []string{"+0.3Mi"}},
+		{"duration", "job  5m3s", "job  5m40s", "", []string{"+37s"}},
+		{"durationAcrossUnits", "pod  59s", "pod  2m5s", "", []string{"+1m6s"}},
+		{"millicores", "pod  250m", "pod  300m", "", []string{"+50m"}},
+		{"percent", "/  46%", "/  48%", "", []string{"+2%"}},
+		{"notNumeric", "pod Pending", "pod Running", "", nil},
+		{"kindMismatch", "x 5Mi", "x 5s", "", nil},
+		{"countDiffers", "a 1 2", "a 3", "", nil},
+		{"ignored", "rx 10 took 45ms", "rx 12 took 48ms", `\d+ms`, []string{"+2"}},
+		{"tableCell", "NAME  RESTARTS\nweb   3", "NAME  RESTARTS\nweb   5", "", []string{"+2"}},
+		{"tableCellWithSpaces", "NAME  STATUS\nweb   Up 5 minutes", "NAME  STATUS\nweb   Up 7 minutes", "", []string{"+2"}},
+		{"tableCellTwoValues", "NAME  PROGRESS\nweb   5 of 10", "NAME  PROGRESS\nweb   6 of 12", "", []string{"+1, +2"}},
+	}
+	for _, c := range cases {
+		var opts Options
+		if c.ignore != "" {
+			opts.Ignore = []*regexp.Regexp{regexp.MustCompile(c.ignore)}
+		}
+		lines := opts.Align(c.old, c.new).Lines()
+		ln := lines[len(lines)-1]
+		deltas := Deltas(ln)
+		if deltas != nil && len(deltas) != len(ln.Spans) {
+			t.Fatalf("%s: %d deltas for %d spans", c.name, len(deltas), len(ln.Spans))
+		}
+		var got []string
+		for _, d := range deltas {
+			if d != "" {
+				got = append(got, d)
+			}
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: got %+v want %+v", c.name, got, c.want)
+		}
+	}
+}
+
+// A delta goes on the last span of its field, so a note placed after that span follows the
+// whole value ("48%", not "48").
+func TestDeltasOnFieldsLastSpan(t *testing.T) {
+	ln := Align("/  46% used", "/  48% used").Lines()[0]
+	deltas := Deltas(ln)
+	if deltas == nil {
+		t.Fatalf("no deltas for %+v", ln.Spans)
+	}
+	for i, d := range deltas {
+		if d != "" && (ln.Spans[i].Text != "%" || d != "+2%") {
+			t.Errorf("delta %q on span %q, want +2%% on %q", d, ln.Spans[i].Text, "%")
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package diff
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Key names the columns that identify a row (a pod's NAME, or NAMESPACE and NAME together),
+// for output whose identity the similarity heuristics get wrong: rows that share most of
+// their tokens, or whose volatile fields outnumber their identifying ones. Columns are
+// numbered from 1 or named by their header; each snapshot resolves them against its own
+// layout (the table columns when detected, else its whitespace fields).
+type Key struct {
+	Columns []int    // 1-based column numbers
+	Names   []string // header names, matched case-insensitively
+}
+
+// ParseKey parses a -key value: a comma-separated list of column numbers and header names
+// ("1", "NAME,NAMESPACE", "2,NAME").
+func ParseKey(spec string) (Key, error) {
+	var k Key
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return Key{}, fmt.Errorf("empty column in key %q", spec)
+		}
+		if n, err := strconv.Atoi(part); err == nil {
+			if n < 1 {
+				return Key{}, errors.New("key columns are numbered from 1")
+			}
+			k.Columns = append(k.Columns, n)
+			continue
+		}
+		k.Names = append(k.Names, part)
+	}
+	return k, nil
+}
+
+// IsZero reports whether k selects no columns (row identity is inferred).
+func (k Key) IsZero() bool { return len(k.Columns) == 0 && len(k.Names) == 0 }
+
+// values returns each line's key (its key cells joined; "" when they are all empty), or nil
+// when a named column is missing from the snapshot's header, in which case rows pair by
+// similarity alone.
+func (k Key) values(lines []string, t *table) []string {
+	split := func(l string) []string { return strings.Fields(l) }
+	var header []string
+	if t != nil {
+		split = func(l string) []string {
+			cs := t.cells(l)
+			out := make([]string, len(cs))
+			for i, c := range cs {
+				out[i] = c.text
+			}
+			return out
+		}
+		header = t.headers
+	} else {
+		for _, l := range lines {
+			if strings.TrimSpace(l) != "" {
+				header = split(l)
+				break
+			}
+		}
+	}
+
+	cols := make([]int, 0, len(k.Columns)+len(k.Names))
+	for _, c := range k.Columns {
+		cols = append(cols, c-1)
+	}
+	for _, name := range k.Names {
+		i := indexFold(header, name)
+		if i < 0 {
+			return nil
+		}
+		cols = append(cols, i)
+	}
+
+	vals := make([]string, len(lines))
+	for i, l := range lines {
+		cells := split(l)
+		parts := make([]string, len(cols))
+		empty := true
+		for p, c := range cols {
+			if c < len(cells) {
+				parts[p] = cells[c]
+				empty = empty && cells[c] == ""
+			}
+		}
+		if !empty {
+			vals[i] = strings.Join(parts, "\x00")
+		}
+	}
+	return vals
+}
+
+// uniqueOnly blanks the keys that more than one line shares: those do not name a single row,
+// so they cannot pair a row that moved.
+func uniqueOnly(keys []string) []string {
+	count := make(map[string]int, len(keys))
+	for _, k := range keys {
+		count[k]++
+	}
+	out := make([]string, len(keys))
+	for i, k := range keys {
+		if count[k] == 1 {
+			out[i] = k
+		}
+	}
+	return out
+}
+
+func indexFold(list []string, s string) int {
+	for i, v := range list {
+		if strings.EqualFold(v, s) {
+			return i
+		}
+	}
+	return -1
+}
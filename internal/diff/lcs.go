@@ -1,6 +1,9 @@
 package diff
 
-import "slices"
+import (
+	"cmp"
+	"slices"
+)
 
 // lcs returns matched index pairs {i, j} in increasing order: an order-preserving set of
 // correspondences that maximizes the total weight, where weight(i, j) > 0 means i and j may
@@ -43,3 +46,33 @@ func lcs(r, c int, weight func(i, j int) float64) [][2]int {
 	slices.Reverse(pairs)
 	return pairs
 }
+
+// longestIncreasing returns the positions, in order, of a longest strictly increasing
+// subsequence of seq (patience sorting, O(n log n)). It is the move-tolerant half of patience
+// diff: of a set of unambiguous correspondences listed in old order, the ones increasing in
+// new order are the largest set that can all hold without crossing; the rest moved.
+func longestIncreasing(seq []int) []int {
+	var tails []int               // tails[k]: position of the smallest tail of an increasing run of length k+1
+	prev := make([]int, len(seq)) // predecessor position in the run ending at each position
+	for p, v := range seq {
+		k, _ := slices.BinarySearchFunc(tails, v, func(t, v int) int { return cmp.Compare(seq[t], v) })
+		if k > 0 {
+			prev[p] = tails[k-1]
+		} else {
+			prev[p] = -1
+		}
+		if k == len(tails) {
+			tails = append(tails, p)
+		} else {
+			tails[k] = p
+		}
+	}
+	out := make([]int, len(tails))
+	if len(tails) == 0 {
+		return out
+	}
+	for k, p := len(tails)-1, tails[len(tails)-1]; k >= 0; k, p = k-1, prev[p] {
+		out[k] = p
+	}
+	return out
+}
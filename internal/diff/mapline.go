@@ -2,12 +2,15 @@ package diff
 
 // MapLine returns the new-output line index corresponding to old-output line idx, so a
 // caller can keep a scroll or cursor position anchored to the same content across a refresh.
-// A matched line maps to its counterpart; a deleted line maps to the position where it was;
-// an unrelocatable line (coarse fallback, nothing similar) returns idx unchanged.
+// A matched or moved line maps to its counterpart; a deleted line maps to the position where
+// it was; an unrelocatable line (coarse fallback, nothing similar) returns idx unchanged.
 func (a Alignment) MapLine(idx int) int {
 	if idx < 0 {
 		return idx
 	}
+	if j, ok := a.movedTo[idx]; ok {
+		return j
+	}
 	if a.coarse {
 		return a.mapLineByScan(idx)
 	}
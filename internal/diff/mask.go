@@ -0,0 +1,114 @@
+package diff
+
+import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// maskText stands in for every Options.Ignore match. Any two masked values compare equal,
+// whatever their length, so "Last updated: 9:59:59" and "Last updated: 10:00:00" are the same
+// line.
+const maskText = "…"
+
+// Masked returns text with every match of o.Ignore replaced by a placeholder, line by line
+// (a pattern never matches across a newline). Two outputs that differ only inside ignored
+// matches mask to the same string, which is what callers comparing outputs for novelty want.
+// Without Ignore patterns text is returned unchanged.
+func (o Options) Masked(text string) string {
+	if len(o.Ignore) == 0 {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, l := range lines {
+		lines[i], _ = o.maskLine(l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// maskLine masks line and returns, for every rune offset of the masked line (and its end),
+// the corresponding rune offset in line, so spans computed on the masked line can be mapped
+// back onto the raw one. A placeholder maps to the start of its match, the offset after it to
+// the match's end.
+func (o Options) maskLine(line string) (string, []int) {
+	var ranges [][2]int // byte ranges of the matches, merged below
+	for _, re := range o.Ignore {
+		for _, m := range re.FindAllStringIndex(line, -1) {
+			if m[1] > m[0] {
+				ranges = append(ranges, [2]int{m[0], m[1]})
+			}
+		}
+	}
+	if len(ranges) == 0 {
+		return line, identityMap(utf8.RuneCountInString(line))
+	}
+	slices.SortFunc(ranges, func(x, y [2]int) int { return x[0] - y[0] })
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		if last := &merged[len(merged)-1]; r[0] <= last[1] {
+			last[1] = max(last[1], r[1])
+		} else {
+			merged = append(merged, r)
+		}
+	}
+
+	var b strings.Builder
+	var toRaw []int
+	rawOff, pos := 0, 0 // rune offset in line, byte position in line
+	copyRunes := func(end int) {
+		for pos < end {
+			_, size := utf8.DecodeRuneInString(line[pos:])
+			b.WriteString(line[pos : pos+size])
+			toRaw = append(toRaw, rawOff)
+			rawOff++
+			pos += size
+		}
+	}
+	for _, r := range merged {
+		copyRunes(r[0])
+		for _, mr := range maskText {
+			b.WriteRune(mr)
+			toRaw = append(toRaw, rawOff)
+		}
+		rawOff += utf8.RuneCountInString(line[r[0]:r[1]])
+		pos = r[1]
+	}
+	copyRunes(len(line))
+	toRaw = append(toRaw, rawOff)
+	return b.String(), toRaw
+}
+
+func identityMap(n int) []int {
+	m := make([]int, n+1)
+	for i := range m {
+		m[i] = i
+	}
+	return m
+}
+
+// unmaskSpans maps spans of a masked line back onto raw, its unmasked text: each span keeps
+// its Changed flag and takes the raw runes its masked range covers. A placeholder falls in
+// an unchanged span whenever both sides had a match there, so an ignored value that changed
+// is not highlighted. A span whose text or Old holds a placeholder loses its Old, so no delta
+// is ever taken of an ignored value.
+func unmaskSpans(spans []Span, raw string, toRaw []int) []Span {
+	if spans == nil {
+		return nil
+	}
+	runes := []rune(raw)
+	out := make([]Span, 0, len(spans))
+	off := 0
+	for _, s := range spans {
+		n := utf8.RuneCountInString(s.Text)
+		lo, hi := toRaw[off], toRaw[off+n]
+		off += n
+		if hi > lo {
+			old := s.Old
+			if strings.Contains(s.Text, maskText) || strings.Contains(old, maskText) {
+				old = ""
+			}
+			out = append(out, Span{Text: string(runes[lo:hi]), Changed: s.Changed, Old: old})
+		}
+	}
+	return out
+}
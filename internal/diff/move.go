@@ -0,0 +1,235 @@
+package diff
+
+import "strings"
+
+// identities returns each line's identity token: its field in column col (a whitespace field
+// position; see stableColumn) when that occurs on no other line of the snapshot, else its
+// leftmost field that does ("" when every field repeats elsewhere). For tabular output this
+// is the row's key column (a pod NAME; for `ps aux`, where USER repeats, the PID), found
+// without knowing the layout. A token unique in both snapshots names the same row in each,
+// wherever the row sits.
+func identities(lines []string, col int) []string {
+	count := tokenCounts(lines)
+	keys := make([]string, len(lines))
+	for i, l := range lines {
+		fs := strings.Fields(l)
+		if col >= 0 && col < len(fs) && count[fs[col]] == 1 {
+			keys[i] = fs[col]
+			continue
+		}
+		for _, f := range fs {
+			if count[f] == 1 {
+				keys[i] = f
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// tokenCounts counts, per whitespace field, the lines it occurs on.
+func tokenCounts(lines []string) map[string]int {
+	count := make(map[string]int)
+	for _, l := range lines {
+		for f := range tokenSet(l) {
+			count[f]++
+		}
+	}
+	return count
+}
+
+// stableColumn returns the column that most often holds the same token, unique in both
+// snapshots, on both sides of a row the alignment matched (the leftmost on a tie), or -1
+// when none does. The leftmost unique field is often a volatile value (an AGE of 5m where
+// STATUS repeats); one that a volatile token of another row happens to take on in the new
+// frame ("6m") would name the wrong row and turn an edit into a delete and an add. The
+// column whose values survive the tick is the key column.
+func (a *Alignment) stableColumn() int {
+	oldCount, newCount := tokenCounts(a.oldLines), tokenCounts(a.newLines)
+	var score []int
+	for _, o := range a.ops {
+		if o.kind != opMatch {
+			continue
+		}
+		of, nf := strings.Fields(a.oldLines[o.oldIdx]), strings.Fields(a.newLines[o.newIdx])
+		for k := range min(len(of), len(nf)) {
+			if of[k] == nf[k] && oldCount[of[k]] == 1 && newCount[nf[k]] == 1 {
+				for len(score) <= k {
+					score = append(score, 0)
+				}
+				score[k]++
+			}
+		}
+	}
+	best := -1
+	for k, n := range score {
+		if n > 0 && (best < 0 || n > score[best]) {
+			best = k
+		}
+	}
+	return best
+}
+
+// keyIndex maps each non-empty identity token to its line.
+func keyIndex(keys []string) map[string]int {
+	idx := make(map[string]int, len(keys))
+	for i, k := range keys {
+		if k != "" {
+			idx[k] = i
+		}
+	}
+	return idx
+}
+
+// detectMoves re-pairs rows by identity token after the order-preserving alignment, so
+// reordered output (`kubectl top --sort-by`, `ps --sort`) reads as rows that moved rather
+// than rows that all changed:
+//
+//  1. A similarity match that its identity tokens contradict (the old row's token names a
+//     different new row, or vice versa) is split into a delete and an insert: two rows
+//     with the same CPU and memory columns look alike, but they are different pods.
+//  2. Unmatched old and new rows sharing an identity token are paired.
+//  3. Within each replace block (the deletes and inserts between two matches), the largest
+//     order-preserving subset of those pairs becomes ordinary matches — an in-place edit.
+//     The remaining pairs, crossing each other or spanning blocks, are moves: they stay
+//     a delete and an insert in ops and are recorded in movedTo/movedFrom.
+//
+// With an Options.Key the identity is the row's key instead, when no other row shares it.
+func (a *Alignment) detectMoves() {
+	col := a.stableColumn()
+	oldKeys, newKeys := identities(a.oldLines, col), identities(a.newLines, col)
+	if a.oldKeys != nil {
+		oldKeys, newKeys = uniqueOnly(a.oldKeys), uniqueOnly(a.newKeys)
+	}
+	oldAt, newAt := keyIndex(oldKeys), keyIndex(newKeys)
+
+	contradicted := func(o op) bool {
+		if a.oldLines[o.oldIdx] == a.newLines[o.newIdx] {
+			return false
+		}
+		if j, ok := newAt[oldKeys[o.oldIdx]]; ok && j != o.newIdx {
+			return true
+		}
+		i, ok := oldAt[newKeys[o.newIdx]]
+		return ok && i != o.oldIdx
+	}
+
+	// Split contradicted matches, then pair the unmatched rows by identity.
+	split := make([]op, 0, len(a.ops))
+	unmatchedNew := make(map[int]bool)
+	for _, o := range a.ops {
+		if o.kind == opMatch && contradicted(o) {
+			split = append(split, op{opDelete, o.oldIdx, -1}, op{opInsert, -1, o.newIdx})
+			unmatchedNew[o.newIdx] = true
+			continue
+		}
+		if o.kind == opInsert {
+			unmatchedNew[o.newIdx] = true
+		}
+		split = append(split, o)
+	}
+	partner := make(map[int]int) // unmatched old -> unmatched new with the same identity
+	for _, o := range split {
+		if o.kind != opDelete {
+			continue
+		}
+		if j, ok := newAt[oldKeys[o.oldIdx]]; ok && unmatchedNew[j] {
+			partner[o.oldIdx] = j
+		}
+	}
+	if len(partner) == 0 {
+		a.ops = split
+		return
+	}
+
+	// Rebuild each replace block around its in-place pairs.
+	ops := make([]op, 0, len(split))
+	var dels, ins []int
+	flush := func() {
+		ops = append(ops, a.pairBlock(dels, ins, partner)...)
+		dels, ins = dels[:0], ins[:0]
+	}
+	for _, o := range split {
+		switch o.kind {
+		case opDelete:
+			dels = append(dels, o.oldIdx)
+		case opInsert:
+			ins = append(ins, o.newIdx)
+		case opMatch:
+			flush()
+			ops = append(ops, o)
+		}
+	}
+	flush()
+	a.ops = ops
+}
+
+// pairBlock emits the ops for one replace block: identity pairs that keep their relative
+// order become matches, laid out like alignGap lays out a gap; every other identity pair is
+// recorded as a move and left as a delete and an insert.
+func (a *Alignment) pairBlock(dels, ins []int, partner map[int]int) []op {
+	if len(dels) == 0 && len(ins) == 0 {
+		return nil
+	}
+	insPos := make(map[int]int, len(ins))
+	for p, j := range ins {
+		insPos[j] = p
+	}
+	var local [][2]int // {del position, ins position}
+	for p, i := range dels {
+		j, ok := partner[i]
+		if !ok {
+			continue
+		}
+		if q, ok := insPos[j]; ok {
+			local = append(local, [2]int{p, q})
+		} else {
+			a.recordMove(i, j) // the partner sits in another block
+		}
+	}
+	// dels is in old order; keep the longest run that is increasing in new order too.
+	inPlace := make(map[int]bool, len(local))
+	seq := make([]int, len(local))
+	for k, pr := range local {
+		seq[k] = pr[1]
+	}
+	for _, k := range longestIncreasing(seq) {
+		inPlace[local[k][0]] = true
+	}
+
+	ops := make([]op, 0, len(dels)+len(ins))
+	di, nj := 0, 0
+	emitGap := func(dEnd, nEnd int) {
+		for ; di < dEnd; di++ {
+			ops = append(ops, op{opDelete, dels[di], -1})
+		}
+		for ; nj < nEnd; nj++ {
+			ops = append(ops, op{opInsert, -1, ins[nj]})
+		}
+	}
+	for _, pr := range local {
+		if !inPlace[pr[0]] {
+			continue
+		}
+		emitGap(pr[0], pr[1])
+		ops = append(ops, op{opMatch, dels[pr[0]], ins[pr[1]]})
+		di, nj = pr[0]+1, pr[1]+1
+	}
+	emitGap(len(dels), len(ins))
+
+	for _, pr := range local {
+		if !inPlace[pr[0]] {
+			a.recordMove(dels[pr[0]], ins[pr[1]])
+		}
+	}
+	return ops
+}
+
+func (a *Alignment) recordMove(oldIdx, newIdx int) {
+	if a.movedTo == nil {
+		a.movedTo = make(map[int]int)
+		a.movedFrom = make(map[int]int)
+	}
+	a.movedTo[oldIdx] = newIdx
+	a.movedFrom[newIdx] = oldIdx
+}
@@ -0,0 +1,164 @@
+package diff
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// table is the column layout of whitespace-aligned tabular output (kubectl, docker ps, ss,
+// df): column boundaries are the gutters, runs of positions that are blank on every line,
+// and the header row is the first non-blank line, which must name every column. Cells never
+// split a word, since a gutter is blank on every row; a column whose header or values hold
+// single spaces ("CONTAINER ID", "Up 5 minutes") stays one cell.
+type table struct {
+	starts  []int    // rune offset where each column's content begins; cell k spans [starts[k], starts[k+1])
+	headers []string // the header row's trimmed cells
+}
+
+// maxHeaderWords is the most words a column title may have ("CONTAINER ID", "Mounted on",
+// "Local Address:Port").
+const maxHeaderWords = 2
+
+// cell is one cell's trimmed content within a line, as a rune range.
+type cell struct {
+	lo, hi int
+	text   string
+}
+
+// detectTable returns the layout of lines, or nil when they do not read as a table: fewer
+// than two non-blank lines, fewer than two columns, tabs (which do not occupy a fixed number
+// of positions), or a header with an empty column or one of more than maxHeaderWords words.
+// The last catches single-space-separated output whose words happen not to line up, where
+// the gutters merge several columns into one.
+func detectTable(lines []string) *table {
+	var occupied, inData []bool // a non-space rune at x on any row / on any row but the header
+	header, rows := -1, 0
+	for i, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		if strings.ContainsRune(l, '\t') {
+			return nil
+		}
+		if header < 0 {
+			header = i
+		}
+		rows++
+		for x, r := range []rune(l) {
+			for x >= len(occupied) {
+				occupied, inData = append(occupied, false), append(inData, false)
+			}
+			if !unicode.IsSpace(r) {
+				occupied[x] = true
+				inData[x] = inData[x] || i != header
+			}
+		}
+	}
+	if rows < 2 {
+		return nil
+	}
+	var runs []int // starts of the occupied runs
+	for x, occ := range occupied {
+		if occ && (x == 0 || !occupied[x-1]) {
+			runs = append(runs, x)
+		}
+	}
+	// A run the header leaves blank is a value that holds spaces ("Up 5 minutes" under
+	// STATUS), and one only the header fills is the rest of a title ("on" of "Mounted on"
+	// over short mount points): neither is a column, so merge it into the one to its left.
+	t := &table{}
+	hdr := []rune(lines[header])
+	for k, x := range runs {
+		end := len(occupied)
+		if k+1 < len(runs) {
+			end = runs[k+1]
+		}
+		titled := strings.TrimSpace(string(hdr[min(x, len(hdr)):min(end, len(hdr))])) != ""
+		if len(t.starts) > 0 && (!titled || !slices.Contains(inData[x:end], true)) {
+			continue
+		}
+		t.starts = append(t.starts, x)
+	}
+	if len(t.starts) < 2 {
+		return nil
+	}
+	for _, c := range t.cells(lines[header]) {
+		if c.text == "" || len(strings.Fields(c.text)) > maxHeaderWords {
+			return nil
+		}
+		t.headers = append(t.headers, c.text)
+	}
+	return t
+}
+
+// sameColumns reports whether two snapshots are both tables with the same header, so that
+// cell k of an old row and cell k of a new row are the same column. When a column is added or
+// renamed the cells mean different things and word-level diffing applies.
+func sameColumns(ot, nt *table) bool {
+	if ot == nil || nt == nil || len(ot.headers) != len(nt.headers) {
+		return false
+	}
+	for k := range ot.headers {
+		if ot.headers[k] != nt.headers[k] {
+			return false
+		}
+	}
+	return true
+}
+
+// cells splits line into one trimmed cell per column. A short line has empty trailing cells.
+func (t *table) cells(line string) []cell {
+	runes := []rune(line)
+	out := make([]cell, len(t.starts))
+	for k := range t.starts {
+		lo := min(t.starts[k], len(runes))
+		if k == 0 {
+			lo = 0 // an indented first column still owns the indent
+		}
+		hi := len(runes)
+		if k+1 < len(t.starts) {
+			hi = min(t.starts[k+1], len(runes))
+		}
+		for lo < hi && unicode.IsSpace(runes[lo]) {
+			lo++
+		}
+		for hi > lo && unicode.IsSpace(runes[hi-1]) {
+			hi--
+		}
+		out[k] = cell{lo, hi, string(runes[lo:hi])}
+	}
+	return out
+}
+
+// cellDiff breaks newLine into spans against oldLine cell by cell: a cell whose trimmed text
+// differs is one Changed span, everything else (unchanged cells, padding) is unchanged, so a
+// column that only shifted because a neighbour widened does not light up, and pairs with the
+// old cell (Span.Old). changed reports whether any cell differs, including one that was
+// cleared and so has nothing to highlight.
+func cellDiff(oldLine string, ot *table, newLine string, nt *table) (spans []Span, changed bool) {
+	oc, nc := ot.cells(oldLine), nt.cells(newLine)
+	runes := []rune(newLine)
+	pos := 0
+	for k, c := range nc {
+		if c.lo == c.hi {
+			changed = changed || oc[k].text != ""
+			continue
+		}
+		if c.lo > pos {
+			spans = append(spans, Span{Text: string(runes[pos:c.lo])})
+		}
+		diff := c.text != oc[k].text
+		changed = changed || diff
+		sp := Span{Text: c.text, Changed: diff}
+		if diff {
+			sp.Old = oc[k].text
+		}
+		spans = append(spans, sp)
+		pos = c.hi
+	}
+	if pos < len(runes) {
+		spans = append(spans, Span{Text: string(runes[pos:])})
+	}
+	return spans, changed
+}
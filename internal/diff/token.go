@@ -6,7 +6,8 @@ import "unicode"
 // rune as its own token (GitHub's \w+|\s+|[^\w\s], unicode-aware). This fine, ordered
 // tokenization drives word-level highlighting (worddiff.go); it is deliberately finer than
 // the whitespace token sets used for row identity (similarity.go), which must stay coarse so
-// shared punctuation does not blur unrelated rows together.
+// shared punctuation does not blur unrelated rows together. A '.' between two digits stays
+// inside its word, so a decimal ("1.25", "1.5Gi") changes, and pairs for a delta, as a whole.
 func tokenize(s string) []string {
 	var tokens []string
 	runes := []rune(s)
@@ -14,7 +15,7 @@ func tokenize(s string) []string {
 		switch r := runes[i]; {
 		case isWord(r):
 			j := i + 1
-			for j < len(runes) && isWord(runes[j]) {
+			for j < len(runes) && (isWord(runes[j]) || isDecimalPoint(runes, j)) {
 				j++
 			}
 			tokens = append(tokens, string(runes[i:j]))
@@ -37,3 +38,8 @@ func tokenize(s string) []string {
 func isWord(r rune) bool {
 	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
 }
+
+// isDecimalPoint reports whether runes[i] is a '.' with a digit on either side.
+func isDecimalPoint(runes []rune, i int) bool {
+	return runes[i] == '.' && i > 0 && i+1 < len(runes) && unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1])
+}
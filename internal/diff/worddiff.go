@@ -4,10 +4,12 @@ import "strings"
 
 // Span is a run of text within a line together with whether it is a meaningful change worth
 // highlighting. Whitespace-only spans are never marked Changed (so shifting column padding
-// does not light up), even when they differ.
+// does not light up), even when they differ. Old is the old text a Changed span replaced,
+// when the diff paired it with some (see tokenDiff and cellDiff), and "" otherwise.
 type Span struct {
 	Text    string
 	Changed bool
+	Old     string
 }
 
 // WordDiff breaks newLine into spans against oldLine: a token with no counterpart in oldLine
@@ -24,7 +26,9 @@ func WordDiff(oldLine, newLine string) []Span {
 
 // tokenDiff returns the new tokens as spans. Byte-identical prefix and suffix tokens are
 // peeled before the LCS so that an inserted duplicate marks the inserted instance rather
-// than an unchanged earlier one (e.g. "a b" -> "a a b" marks the second "a").
+// than an unchanged earlier one (e.g. "a b" -> "a a b" marks the second "a"). Between two
+// matched tokens, the changed new tokens pair by position with the old ones they replace
+// (Span.Old) when both sides have the same number of them.
 func tokenDiff(old, new []string) []Span {
 	n, m := len(old), len(new)
 	p := 0
@@ -52,5 +56,34 @@ func tokenDiff(old, new []string) []Span {
 		changed := j >= p && j < m-s && !matchedMid[j-p] && strings.TrimSpace(t) != ""
 		spans[j] = Span{Text: t, Changed: changed}
 	}
+	oi, nj := p, p
+	for _, pr := range append(pairs, [2]int{n - s - p, m - s - p}) {
+		pairGap(old[oi:p+pr[0]], spans[nj:p+pr[1]])
+		oi, nj = p+pr[0]+1, p+pr[1]+1
+	}
 	return spans
 }
+
+// pairGap sets Span.Old on the changed spans of gap, the new tokens between two matched
+// ones, from old, the old tokens between the same two: the i-th changed span takes the i-th
+// non-whitespace old token, provided the counts agree.
+func pairGap(old []string, gap []Span) {
+	var olds []string
+	for _, t := range old {
+		if strings.TrimSpace(t) != "" {
+			olds = append(olds, t)
+		}
+	}
+	var changed []int
+	for j, sp := range gap {
+		if sp.Changed {
+			changed = append(changed, j)
+		}
+	}
+	if len(changed) != len(olds) {
+		return
+	}
+	for k, j := range changed {
+		gap[j].Old = olds[k]
+	}
+}
@@ -0,0 +1,254 @@
+// Package events is the machine-readable counterpart to the TUI and the headless change log:
+// an NDJSON stream with one event per execution milestone and one per changed output line,
+// so tooling can react to "row X's STATUS changed" without re-implementing internal/diff's
+// alignment. Every event carries the execution's sequence number (seq) so a consumer can
+// group the events of one execution.
+//
+//	{"event":"exec_started","seq":3,"ts":"…"}
+//	{"event":"exec_finished","seq":3,"ts":"…","duration_ms":41.2,"exit":0}
+//	{"event":"frame_accepted","seq":3,"ts":"…","exit":0,"added":0,"changed":1,"removed":0,"moved":0}
+//	{"event":"line","seq":3,"kind":"changed","index":2,"old_index":2,"text":"…","old_text":"…","spans":[…]}
+//	{"event":"frame_deduplicated","seq":4,"ts":"…"}
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/ivoronin/wch/internal/diff"
+	"github.com/ivoronin/wch/internal/runner"
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// Event names, the "event" field of every record.
+const (
+	ExecStarted       = "exec_started"
+	ExecFinished      = "exec_finished"
+	FrameAccepted     = "frame_accepted"
+	FrameDeduplicated = "frame_deduplicated"
+	LineEvent         = "line"
+)
+
+// Line kinds, the "kind" field of a line event.
+const (
+	KindAdded   = "added"
+	KindChanged = "changed"
+	KindRemoved = "removed"
+	KindMoved   = "moved"
+)
+
+type header struct {
+	Event string    `json:"event"`
+	Seq   int       `json:"seq"`
+	Ts    time.Time `json:"ts,omitzero"`
+}
+
+type execFinished struct {
+	header
+	DurationMs float64 `json:"duration_ms"`
+	Exit       int     `json:"exit"`
+	Error      string  `json:"error,omitempty"`
+	TimedOut   bool    `json:"timed_out,omitempty"`
+	UserMs     float64 `json:"user_ms"`
+	SysMs      float64 `json:"sys_ms"`
+	MaxRSS     int64   `json:"max_rss,omitempty"` // bytes
+}
+
+type frameAccepted struct {
+	header
+	Exit    int `json:"exit"`
+	Added   int `json:"added"`
+	Changed int `json:"changed"`
+	Removed int `json:"removed"`
+	Moved   int `json:"moved"`
+}
+
+// lineRecord describes one non-equal line. Index is the line's position in the new output
+// (-1 for a removed line); OldIndex its position in the previous output (-1 for an added
+// line). Spans lists the changed tokens of a changed line, or of a moved line whose content
+// changed too.
+type lineRecord struct {
+	header
+	Kind     string `json:"kind"`
+	Index    int    `json:"index"`
+	OldIndex int    `json:"old_index"`
+	Text     string `json:"text,omitempty"`
+	OldText  string `json:"old_text,omitempty"`
+	Spans    []span `json:"spans,omitempty"`
+}
+
+// span is one changed token run; Offset counts runes from the start of the line.
+type span struct {
+	Offset int    `json:"offset"`
+	Text   string `json:"text"`
+}
+
+// Sink writes events as NDJSON. It is safe for concurrent use (the TUI runs executions on a
+// Bubble Tea command goroutine and records them on the update goroutine). A nil *Sink
+// discards everything, so callers need no "events enabled?" branches. The first write error
+// is latched: later events are dropped and Err reports it.
+type Sink struct {
+	mu    sync.Mutex
+	enc   *json.Encoder
+	align diff.Options
+	seq   int
+	err   error
+}
+
+// NewJSON returns a Sink writing NDJSON to w, diffing frames under align. The caller owns w
+// and closes it.
+func NewJSON(w io.Writer, align diff.Options) *Sink {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &Sink{enc: enc, align: align}
+}
+
+// Run executes r between an exec_started and an exec_finished event and returns the
+// execution together with its sequence number, to be passed to Frame once the execution has
+// been offered to the session. A nil Sink just executes and returns seq 0.
+func (s *Sink) Run(ctx context.Context, r *runner.Runner) (session.Execution, int) {
+	if s == nil {
+		return r.Execute(ctx), 0
+	}
+	s.mu.Lock()
+	s.seq++
+	seq := s.seq
+	s.mu.Unlock()
+
+	s.emit(header{Event: ExecStarted, Seq: seq, Ts: time.Now()})
+	exec := r.Execute(ctx)
+	s.emit(execFinished{
+		header:     header{Event: ExecFinished, Seq: seq, Ts: exec.Timestamp},
+		DurationMs: millis(exec.Duration),
+		Exit:       exec.ExitCode,
+		Error:      errString(exec.Error),
+		TimedOut:   exec.TimedOut,
+		UserMs:     millis(exec.Usage.User),
+		SysMs:      millis(exec.Usage.Sys),
+		MaxRSS:     exec.Usage.MaxRSS,
+	})
+	return exec, seq
+}
+
+// Frame reports what session.RecordIfChanged did with execution seq: frame_deduplicated
+// when it was dropped, otherwise frame_accepted followed by one line event per added,
+// changed, moved, or removed line relative to prevOutput (the output of the previous history
+// frame; "" for the first frame, whose lines are all added).
+func (s *Sink) Frame(seq int, prevOutput string, exec session.Execution, added bool) {
+	if s == nil {
+		return
+	}
+	if !added {
+		s.emit(header{Event: FrameDeduplicated, Seq: seq, Ts: exec.Timestamp})
+		return
+	}
+	oldLines := splitLines(ansi.Strip(prevOutput))
+	lines := s.align.Align(ansi.Strip(prevOutput), ansi.Strip(exec.Output())).LinesWithDeleted()
+	recs, summary := lineRecords(lines, oldLines)
+	summary.header = header{Event: FrameAccepted, Seq: seq, Ts: exec.Timestamp}
+	summary.Exit = exec.ExitCode
+	s.emit(summary)
+	for _, r := range recs {
+		r.header = header{Event: LineEvent, Seq: seq}
+		s.emit(r)
+	}
+}
+
+// Err returns the first write error, if any. Safe on a nil Sink.
+func (s *Sink) Err() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *Sink) emit(v any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return
+	}
+	s.err = s.enc.Encode(v)
+}
+
+// lineRecords converts the diff (with deletions) into line records in output order, a
+// removed line sitting at its old position among the new lines. Index counts new-output
+// lines only. Also returns the per-kind counts for the frame_accepted summary.
+func lineRecords(lines []diff.Line, oldLines []string) ([]lineRecord, frameAccepted) {
+	var recs []lineRecord
+	var sum frameAccepted
+	i := 0 // new-output index
+	for _, ln := range lines {
+		switch ln.Kind {
+		case diff.LineAdded:
+			sum.Added++
+			recs = append(recs, lineRecord{Kind: KindAdded, Index: i, OldIndex: -1, Text: ln.Text})
+		case diff.LineChanged:
+			sum.Changed++
+			recs = append(recs, lineRecord{
+				Kind:     KindChanged,
+				Index:    i,
+				OldIndex: ln.OldIndex,
+				Text:     ln.Text,
+				OldText:  oldLines[ln.OldIndex],
+				Spans:    changedSpans(ln.Spans),
+			})
+		case diff.LineMoved:
+			sum.Moved++
+			rec := lineRecord{Kind: KindMoved, Index: i, OldIndex: ln.OldIndex, Text: ln.Text, Spans: changedSpans(ln.Spans)}
+			if ln.Spans != nil {
+				rec.OldText = oldLines[ln.OldIndex]
+			}
+			recs = append(recs, rec)
+		case diff.LineDeleted:
+			sum.Removed++
+			recs = append(recs, lineRecord{Kind: KindRemoved, Index: -1, OldIndex: ln.OldIndex, OldText: ln.Text})
+			continue
+		}
+		i++
+	}
+	return recs, sum
+}
+
+// changedSpans merges adjacent Changed spans into runs and records each run's rune offset.
+func changedSpans(spans []diff.Span) []span {
+	var out []span
+	off := 0
+	open := false
+	for _, sp := range spans {
+		n := len([]rune(sp.Text))
+		switch {
+		case sp.Changed && open:
+			out[len(out)-1].Text += sp.Text
+		case sp.Changed:
+			out = append(out, span{Offset: off, Text: sp.Text})
+		}
+		open = sp.Changed
+		off += n
+	}
+	return out
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func errString(e error) string {
+	if e == nil {
+		return ""
+	}
+	return e.Error()
+}
+
+func millis(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
@@ -0,0 +1,142 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivoronin/wch/internal/diff"
+	"github.com/ivoronin/wch/internal/runner"
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// decode splits NDJSON output into generic maps for field-level assertions.
+func decode(t *testing.T, out string) []map[string]any {
+	t.Helper()
+	var recs []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var m map[string]any
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", line, err)
+		}
+		recs = append(recs, m)
+	}
+	return recs
+}
+
+func TestRunEmitsStartedAndFinished(t *testing.T) {
+	var b strings.Builder
+	s := NewJSON(&b, diff.Options{})
+	exec, seq := s.Run(context.Background(), runner.New("echo hi; exit 3"))
+	if seq != 1 || exec.ExitCode != 3 {
+		t.Fatalf("seq=%d exit=%d want 1, 3", seq, exec.ExitCode)
+	}
+	recs := decode(t, b.String())
+	if len(recs) != 2 {
+		t.Fatalf("got %d events want 2: %s", len(recs), b.String())
+	}
+	if recs[0]["event"] != ExecStarted || recs[1]["event"] != ExecFinished {
+		t.Errorf("events=%v,%v", recs[0]["event"], recs[1]["event"])
+	}
+	if recs[1]["exit"] != float64(3) || recs[1]["seq"] != float64(1) {
+		t.Errorf("exec_finished=%v", recs[1])
+	}
+	if _, ok := recs[1]["duration_ms"]; !ok {
+		t.Errorf("exec_finished lacks duration_ms: %v", recs[1])
+	}
+}
+
+func TestFrameLineRecords(t *testing.T) {
+	var b strings.Builder
+	s := NewJSON(&b, diff.Options{})
+	prev := "NAME READY STATUS AGE\npod-a 1/1 Running 9m\npod-b 0/1 Pending 5m\npod-c 1/1 Running 2d"
+	cur := session.Execution{
+		Timestamp: time.Date(2026, 5, 30, 12, 0, 0, 0, time.UTC),
+		Stdout:    "NAME READY STATUS AGE\npod-b 0/1 \x1b[32mRunning\x1b[0m 5m\npod-c 1/1 Running 2d\npod-d 0/1 Pending 1s",
+	}
+	s.Frame(7, prev, cur, true)
+
+	recs := decode(t, b.String())
+	if recs[0]["event"] != FrameAccepted {
+		t.Fatalf("first event=%v want %s", recs[0]["event"], FrameAccepted)
+	}
+	if recs[0]["added"] != float64(1) || recs[0]["changed"] != float64(1) || recs[0]["removed"] != float64(1) {
+		t.Errorf("summary=%v want 1 added, 1 changed, 1 removed", recs[0])
+	}
+	byKind := map[string]map[string]any{}
+	for _, r := range recs[1:] {
+		if r["event"] != LineEvent || r["seq"] != float64(7) {
+			t.Errorf("line record=%v", r)
+		}
+		byKind[r["kind"].(string)] = r
+	}
+	changed := byKind[KindChanged]
+	if changed["text"] != "pod-b 0/1 Running 5m" || changed["old_text"] != "pod-b 0/1 Pending 5m" || changed["index"] != float64(1) {
+		t.Errorf("changed=%v", changed)
+	}
+	spans := changed["spans"].([]any)
+	if len(spans) != 1 || spans[0].(map[string]any)["text"] != "Running" || spans[0].(map[string]any)["offset"] != float64(10) {
+		t.Errorf("spans=%v want [{offset 10, Running}]", spans)
+	}
+	if byKind[KindAdded]["text"] != "pod-d 0/1 Pending 1s" || byKind[KindAdded]["old_index"] != float64(-1) {
+		t.Errorf("added=%v", byKind[KindAdded])
+	}
+	if byKind[KindRemoved]["old_text"] != "pod-a 1/1 Running 9m" || byKind[KindRemoved]["index"] != float64(-1) {
+		t.Errorf("removed=%v", byKind[KindRemoved])
+	}
+}
+
+func TestFrameMovedRecord(t *testing.T) {
+	var b strings.Builder
+	s := NewJSON(&b, diff.Options{})
+	prev := "NAME CPU MEM\npod-a 90m 1Gi\npod-b 40m 2Gi"
+	s.Frame(2, prev, session.Execution{Stdout: "NAME CPU MEM\npod-b 40m 2Gi\npod-a 90m 1Gi"}, true)
+
+	recs := decode(t, b.String())
+	if recs[0]["moved"] != float64(1) || recs[0]["changed"] != float64(0) {
+		t.Errorf("summary=%v want 1 moved, 0 changed", recs[0])
+	}
+	if len(recs) != 2 {
+		t.Fatalf("got %d events want 2: %s", len(recs), b.String())
+	}
+	if mv := recs[1]; mv["kind"] != KindMoved || mv["index"] != float64(2) || mv["old_index"] != float64(1) || mv["text"] != "pod-a 90m 1Gi" {
+		t.Errorf("moved=%v", mv)
+	}
+}
+
+func TestFrameDeduplicated(t *testing.T) {
+	var b strings.Builder
+	NewJSON(&b, diff.Options{}).Frame(2, "a", session.Execution{Stdout: "a"}, false)
+	recs := decode(t, b.String())
+	if len(recs) != 1 || recs[0]["event"] != FrameDeduplicated {
+		t.Errorf("got %v want one frame_deduplicated", recs)
+	}
+}
+
+// A nil Sink is a usable no-op: Run still executes.
+func TestNilSink(t *testing.T) {
+	var s *Sink
+	exec, seq := s.Run(context.Background(), runner.New("echo hi"))
+	if exec.Stdout != "hi\n" || seq != 0 {
+		t.Errorf("nil Run: stdout=%q seq=%d", exec.Stdout, seq)
+	}
+	s.Frame(seq, "", exec, true)
+	if s.Err() != nil {
+		t.Errorf("nil Err()=%v", s.Err())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestWriteErrorLatched(t *testing.T) {
+	s := NewJSON(failingWriter{}, diff.Options{})
+	s.Frame(1, "", session.Execution{Stdout: "a"}, false)
+	if err := s.Err(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Err()=%v want disk full", err)
+	}
+}
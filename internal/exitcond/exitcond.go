@@ -0,0 +1,105 @@
+// Package exitcond decides when a watch should stop on its own — the scripting counterpart
+// to pressing q. A Conditions value is built once from the CLI flags and consulted after
+// every execution by whichever driver is running the watch (the TUI or the headless loop),
+// so both report the same Reason and therefore the same process exit status.
+package exitcond
+
+import (
+	"regexp"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// Reason names the condition that ended a watch. None means no condition fired (the user
+// quit, or no conditions were configured).
+type Reason int
+
+const (
+	None Reason = iota
+	Changed
+	Matched
+	Unmatched
+	Succeeded
+	Failed
+)
+
+// ExitCode returns the process exit status reported for r. 0, 1 and 2 keep their usual
+// meaning (normal quit, error, bad flag); each condition gets its own status from 3 upward
+// so a script can tell which one fired without parsing output.
+func (r Reason) ExitCode() int {
+	switch r {
+	case Changed:
+		return 3
+	case Matched:
+		return 4
+	case Unmatched:
+		return 5
+	case Succeeded:
+		return 6
+	case Failed:
+		return 7
+	}
+	return 0
+}
+
+// String returns a short human-readable description, used in log lines.
+func (r Reason) String() string {
+	switch r {
+	case Changed:
+		return "output changed"
+	case Matched:
+		return "output matched"
+	case Unmatched:
+		return "output stopped matching"
+	case Succeeded:
+		return "command succeeded"
+	case Failed:
+		return "command failed"
+	}
+	return "none"
+}
+
+// Conditions is the set of exit conditions requested on the command line. The zero value
+// has none enabled. Regexps match against the ANSI-stripped combined output, so a colored
+// command matches the same as its plain form.
+type Conditions struct {
+	OnChange     bool           // -g: the first frame that differs from its predecessor
+	UntilMatch   *regexp.Regexp // -until-match: output matches
+	WhileMatch   *regexp.Regexp // -while-match: output no longer matches
+	UntilSuccess bool           // -until-success: command exits zero
+	UntilFailure bool           // -until-failure: command exits non-zero or fails to run
+}
+
+// Enabled reports whether any condition is configured.
+func (c Conditions) Enabled() bool {
+	return c.OnChange || c.UntilMatch != nil || c.WhileMatch != nil || c.UntilSuccess || c.UntilFailure
+}
+
+// Check evaluates exec against the conditions. changed reports whether the execution was
+// accepted into history as novel AND had a predecessor to differ from — the very first frame
+// is never a change. When several conditions hold at once the first in declaration order
+// wins, so the reported Reason is deterministic.
+func (c Conditions) Check(exec session.Execution, changed bool) Reason {
+	if c.OnChange && changed {
+		return Changed
+	}
+	if c.UntilMatch != nil || c.WhileMatch != nil {
+		out := ansi.Strip(exec.Output())
+		if c.UntilMatch != nil && c.UntilMatch.MatchString(out) {
+			return Matched
+		}
+		if c.WhileMatch != nil && !c.WhileMatch.MatchString(out) {
+			return Unmatched
+		}
+	}
+	succeeded := !exec.Failed()
+	if c.UntilSuccess && succeeded {
+		return Succeeded
+	}
+	if c.UntilFailure && !succeeded {
+		return Failed
+	}
+	return None
+}
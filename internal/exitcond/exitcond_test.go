@@ -0,0 +1,70 @@
+package exitcond
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/ivoronin/wch/internal/session"
+)
+
+func TestCheck(t *testing.T) {
+	ok := session.Execution{Stdout: "pod-1 \x1b[32mRunning\x1b[0m"}
+	failed := session.Execution{Stdout: "boom", ExitCode: 2, Error: errors.New("exit status 2")}
+	notRun := session.Execution{ExitCode: -1, Error: errors.New("exec: not found")}
+	running := regexp.MustCompile(`Running`)
+
+	cases := []struct {
+		name    string
+		cond    Conditions
+		exec    session.Execution
+		changed bool
+		want    Reason
+	}{
+		{"none configured", Conditions{}, ok, true, None},
+		{"change fires on change", Conditions{OnChange: true}, ok, true, Changed},
+		{"change quiet on first/dup", Conditions{OnChange: true}, ok, false, None},
+		{"match ignores ANSI", Conditions{UntilMatch: regexp.MustCompile(`pod-1 Running`)}, ok, false, Matched},
+		{"match not yet", Conditions{UntilMatch: running}, failed, false, None},
+		{"while still matching", Conditions{WhileMatch: running}, ok, false, None},
+		{"while stopped matching", Conditions{WhileMatch: running}, failed, false, Unmatched},
+		{"success", Conditions{UntilSuccess: true}, ok, false, Succeeded},
+		{"success not yet", Conditions{UntilSuccess: true}, failed, false, None},
+		{"failure on exit code", Conditions{UntilFailure: true}, failed, false, Failed},
+		{"failure on start error", Conditions{UntilFailure: true}, notRun, false, Failed},
+		{"failure not yet", Conditions{UntilFailure: true}, ok, false, None},
+		{"change wins over match", Conditions{OnChange: true, UntilMatch: running}, ok, true, Changed},
+	}
+	for _, c := range cases {
+		if got := c.cond.Check(c.exec, c.changed); got != c.want {
+			t.Errorf("%s: Check=%v want %v", c.name, got, c.want)
+		}
+	}
+}
+
+// Every firing Reason maps to a distinct status outside the conventional 0/1 pair.
+func TestExitCodesDistinct(t *testing.T) {
+	if None.ExitCode() != 0 {
+		t.Errorf("None.ExitCode()=%d want 0", None.ExitCode())
+	}
+	seen := map[int]Reason{}
+	for _, r := range []Reason{Changed, Matched, Unmatched, Succeeded, Failed} {
+		code := r.ExitCode()
+		if code < 3 {
+			t.Errorf("%v.ExitCode()=%d, collides with 0/1/2", r, code)
+		}
+		if prev, dup := seen[code]; dup {
+			t.Errorf("%v and %v share exit code %d", prev, r, code)
+		}
+		seen[code] = r
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	if (Conditions{}).Enabled() {
+		t.Errorf("zero Conditions should not be enabled")
+	}
+	if !(Conditions{UntilFailure: true}).Enabled() {
+		t.Errorf("UntilFailure should enable")
+	}
+}
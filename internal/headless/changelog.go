@@ -0,0 +1,96 @@
+package headless
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/ivoronin/wch/internal/diff"
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// changeTimeFmt stamps every change-log entry. RFC 3339 with the local offset keeps the log
+// greppable and unambiguous across a DST switch or a log shipped to another timezone.
+const changeTimeFmt = time.RFC3339
+
+// Span markers in the change log: a changed token is wrapped wdiff-style, so `{+6m+}` reads
+// as "now 6m" without colour.
+const (
+	spanOpen  = "{+"
+	spanClose = "+}"
+)
+
+// writeChange appends one change-log entry for cur, diffed under align against prevOutput (the output
+// of the frame it replaced in history; "" for the first frame, which then lists every line
+// as added). The entry is a header line with the timestamp and exit status, followed by one
+// line per non-equal diff.Line in unified-diff style, removed lines at their old position and
+// moved rows (`>`) at their new one:
+//
+//	=== 2026-05-30T12:00:02Z exit 0
+//	+ pod-0 1/1 Running 1s
+//	~ pod-1 1/1 Running {+6m+}
+//	> pod-3 1/1 Running 6m
+//	- pod-2 1/1 Terminating 5m
+//
+// Text is ANSI-stripped: the log is for reading in a pager or CI console, not a terminal
+// replay (that is what -w is for).
+func writeChange(w io.Writer, align diff.Options, prevOutput string, cur session.Execution) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "=== %s %s\n", cur.Timestamp.Format(changeTimeFmt), exitSummary(cur))
+	for _, ln := range align.Align(ansi.Strip(prevOutput), ansi.Strip(cur.Output())).LinesWithDeleted() {
+		switch ln.Kind {
+		case diff.LineAdded:
+			fmt.Fprintf(bw, "+ %s\n", ln.Text)
+		case diff.LineDeleted:
+			fmt.Fprintf(bw, "- %s\n", ln.Text)
+		case diff.LineChanged:
+			fmt.Fprintf(bw, "~ %s\n", markSpans(ln.Spans))
+		case diff.LineMoved:
+			if ln.Spans == nil {
+				fmt.Fprintf(bw, "> %s\n", ln.Text)
+			} else {
+				fmt.Fprintf(bw, "> %s\n", markSpans(ln.Spans))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// exitSummary renders the exit status for an entry header: the exit code, the timeout
+// ("timed out after 10s"), or the error text when the command could not be run at all (no
+// meaningful exit code).
+func exitSummary(e session.Execution) string {
+	if e.TimedOut {
+		return e.Error.Error()
+	}
+	if e.Error != nil && e.ExitCode < 0 {
+		return "error: " + e.Error.Error()
+	}
+	return fmt.Sprintf("exit %d", e.ExitCode)
+}
+
+// markSpans joins spans back into their line, wrapping each run of Changed spans in the
+// span markers. Adjacent changed spans share one marker pair, so "1/2" reads `{+2+}` rather
+// than fragmenting a multi-token change.
+func markSpans(spans []diff.Span) string {
+	var b strings.Builder
+	open := false
+	for _, s := range spans {
+		if s.Changed && !open {
+			b.WriteString(spanOpen)
+			open = true
+		} else if !s.Changed && open {
+			b.WriteString(spanClose)
+			open = false
+		}
+		b.WriteString(s.Text)
+	}
+	if open {
+		b.WriteString(spanClose)
+	}
+	return b.String()
+}
@@ -0,0 +1,75 @@
+package headless
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivoronin/wch/internal/diff"
+	"github.com/ivoronin/wch/internal/session"
+)
+
+var ts = time.Date(2026, 5, 30, 12, 0, 2, 0, time.UTC)
+
+func TestWriteChangeFirstFrameListsEveryLine(t *testing.T) {
+	var b strings.Builder
+	if err := writeChange(&b, diff.Options{}, "", session.Execution{Timestamp: ts, Stdout: "NAME AGE\npod-1 5m"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "=== 2026-05-30T12:00:02Z exit 0\n+ NAME AGE\n+ pod-1 5m\n"
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+// Equal lines are omitted, changed tokens are marked, removed lines listed, ANSI stripped.
+func TestWriteChangeMarksChangedSpans(t *testing.T) {
+	prev := "NAME READY AGE\npod-1 1/1 5m\npod-2 1/1 5m\npod-3 1/1 5m"
+	cur := session.Execution{
+		Timestamp: ts,
+		Stdout:    "NAME READY AGE\npod-0 0/1 1s\npod-1 1/1 \x1b[32m6m\x1b[0m\npod-2 1/1 5m",
+		ExitCode:  1,
+		Error:     errors.New("exit status 1"),
+	}
+	var b strings.Builder
+	if err := writeChange(&b, diff.Options{}, prev, cur); err != nil {
+		t.Fatal(err)
+	}
+	want := "=== 2026-05-30T12:00:02Z exit 1\n+ pod-0 0/1 1s\n~ pod-1 1/1 {+6m+}\n- pod-3 1/1 5m\n"
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+// Reordered rows are listed once, at their new position, with any changed tokens marked.
+func TestWriteChangeMovedRows(t *testing.T) {
+	prev := "NAME CPU MEM\npod-a 90m 1Gi\npod-b 40m 2Gi\npod-c 10m 3Gi"
+	cur := session.Execution{Timestamp: ts, Stdout: "NAME CPU MEM\npod-b 95m 2Gi\npod-a 90m 1Gi\npod-c 10m 3Gi"}
+	var b strings.Builder
+	if err := writeChange(&b, diff.Options{}, prev, cur); err != nil {
+		t.Fatal(err)
+	}
+	want := "=== 2026-05-30T12:00:02Z exit 0\n> pod-b {+95m+} 2Gi\n"
+	if b.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestWriteChangeStartError(t *testing.T) {
+	var b strings.Builder
+	cur := session.Execution{Timestamp: ts, ExitCode: -1, Error: errors.New("fork/exec: no such file")}
+	if err := writeChange(&b, diff.Options{}, "", cur); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), "=== 2026-05-30T12:00:02Z error: fork/exec: no such file\n") {
+		t.Errorf("header=%q", b.String())
+	}
+}
+
+func TestMarkSpansMergesAdjacent(t *testing.T) {
+	spans := []diff.Span{{Text: "a "}, {Text: "b", Changed: true}, {Text: "-", Changed: true}, {Text: " c"}}
+	if got, want := markSpans(spans), "a {+b-+} c"; got != want {
+		t.Errorf("markSpans=%q want %q", got, want)
+	}
+}
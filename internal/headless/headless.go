@@ -0,0 +1,142 @@
+// Package headless drives a watch without the TUI: the same runner → session pipeline the
+// Bubble Tea model runs, as a plain loop. Used when there is no terminal to draw on (CI jobs,
+// scripts blocking on an exit condition) and for -headless, which additionally prints a
+// timestamped change log of every accepted frame (changelog.go).
+package headless
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ivoronin/wch/internal/diff"
+	"github.com/ivoronin/wch/internal/events"
+	"github.com/ivoronin/wch/internal/exitcond"
+	"github.com/ivoronin/wch/internal/runner"
+	"github.com/ivoronin/wch/internal/schedule"
+	"github.com/ivoronin/wch/internal/session"
+	"github.com/ivoronin/wch/internal/trigger"
+)
+
+// Config holds the headless loop's settings.
+type Config struct {
+	Interval time.Duration
+	Align    diff.Options // row identity for the change log's diff
+	Exit     exitcond.Conditions
+	Log      io.Writer          // change log destination; nil = run quietly
+	Events   *events.Sink       // NDJSON event stream; nil = none
+	Schedule schedule.Scheduler // when to run; nil = every Interval
+	Precise  bool               // tick at the schedule's times, not after each result
+	Overlap  schedule.Policy    // what a Precise tick or a Trigger does while a run is in flight
+	Trigger  trigger.Source     // changes that start a run; ticks then poll only when Interval or Schedule is set
+}
+
+// result is one finished run, as handed back by the goroutine that ran it.
+type result struct {
+	exec session.Execution
+	seq  int
+}
+
+// Run executes r on cfg.Schedule (every cfg.Interval without one), asking it for the next
+// run once the previous result arrives, matching the TUI's tick, or as each tick fires under
+// cfg.Precise or alongside cfg.Trigger, whose every event starts a run too. It records each
+// result into s and returns once an exit condition fires. It
+// also returns when ctx is cancelled (Reason None, ctx.Err()) or when an armed recording
+// fails to write — unlike the TUI there is nobody to show a warning bubble to, so a broken
+// recording ends the run rather than silently continuing unrecorded. A failed write to
+// cfg.Log (e.g. a closed pipe) ends the run the same way.
+func Run(ctx context.Context, r *runner.Runner, s *session.Session, cfg Config) (exitcond.Reason, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // kills runs still in flight when an exit condition fires
+	results := make(chan result)
+	gate := schedule.Gate{Policy: cfg.Overlap}
+	sched := cfg.Schedule
+	if sched == nil {
+		sched = schedule.ForInterval(cfg.Interval, cfg.Precise)
+	}
+	ticking := cfg.Trigger == nil || cfg.Interval > 0 || cfg.Schedule != nil
+	fixedRate := cfg.Precise || cfg.Trigger != nil
+	var triggered <-chan string // nil (never ready) without a trigger
+	if cfg.Trigger != nil {
+		triggered = cfg.Trigger.Events()
+	}
+	next := func() time.Duration { return time.Until(sched.Next(time.Now())) }
+	start := func() {
+		gate.Start()
+		go func() {
+			exec, seq := cfg.Events.Run(ctx, r)
+			select {
+			case results <- result{exec, seq}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	tick := time.NewTimer(0)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return exitcond.None, ctx.Err()
+		case <-tick.C:
+			if fixedRate && ticking {
+				tick.Reset(next())
+			}
+			if gate.Tick() {
+				start()
+			}
+		case _, ok := <-triggered:
+			if !ok {
+				triggered = nil
+			} else if gate.Tick() {
+				start()
+			}
+		case res := <-results:
+			if ctx.Err() != nil {
+				return exitcond.None, ctx.Err()
+			}
+			keep, runQueued := gate.Finish(res.exec.Timestamp)
+			if keep {
+				added, reason, err := record(s, cfg, res)
+				if reason != exitcond.None || err != nil {
+					return reason, err
+				}
+				if fb, ok := sched.(schedule.Feedback); ok {
+					fb.Observe(res.exec.Failed(), added)
+				}
+			}
+			switch {
+			case runQueued:
+				start()
+			case !fixedRate:
+				tick.Reset(next())
+			}
+		}
+	}
+}
+
+// record adds one result to s, reports it to the event stream and change log, and checks
+// the exit conditions against it. added reports whether the result was a new frame.
+func record(s *session.Session, cfg Config, res result) (added bool, reason exitcond.Reason, err error) {
+	exec := res.exec
+	// Capture the predecessor before recording: a MaxHistory of 1 evicts it.
+	var prevOutput string
+	n := s.History.Len()
+	hadPrior := n > 0
+	if hadPrior {
+		last := s.History.At(n - 1)
+		prevOutput = last.Output()
+	}
+	added, _, err = s.RecordIfChanged(exec)
+	if err != nil {
+		return false, exitcond.None, fmt.Errorf("recording: %w", err)
+	}
+	cfg.Events.Frame(res.seq, prevOutput, exec, added)
+	if added && cfg.Log != nil {
+		if err := writeChange(cfg.Log, cfg.Align, prevOutput, exec); err != nil {
+			return false, exitcond.None, fmt.Errorf("change log: %w", err)
+		}
+	}
+	return added, cfg.Exit.Check(exec, added && hadPrior), nil
+}
@@ -0,0 +1,124 @@
+package headless
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ivoronin/wch/internal/exitcond"
+	"github.com/ivoronin/wch/internal/runner"
+	"github.com/ivoronin/wch/internal/schedule"
+	"github.com/ivoronin/wch/internal/session"
+)
+
+func run(t *testing.T, ctx context.Context, command string, cond exitcond.Conditions) (exitcond.Reason, *session.Session, error) {
+	t.Helper()
+	s := session.NewSession(command, time.Millisecond)
+	reason, err := Run(ctx, runner.New(command), s, Config{Interval: time.Millisecond, Exit: cond})
+	return reason, s, err
+}
+
+// -g must not fire on the first frame; it fires on the first one that differs.
+func TestRunExitsOnChange(t *testing.T) {
+	reason, s, err := run(t, context.Background(), "date +%s%N", exitcond.Conditions{OnChange: true})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if reason != exitcond.Changed {
+		t.Errorf("reason=%v want %v", reason, exitcond.Changed)
+	}
+	if s.History.Len() != 2 {
+		t.Errorf("History len=%d want 2 (first frame + the change)", s.History.Len())
+	}
+}
+
+func TestRunExitsOnMatch(t *testing.T) {
+	reason, _, err := run(t, context.Background(), "echo ready", exitcond.Conditions{UntilMatch: regexp.MustCompile(`^ready`)})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if reason != exitcond.Matched {
+		t.Errorf("reason=%v want %v", reason, exitcond.Matched)
+	}
+}
+
+func TestRunExitsOnFailure(t *testing.T) {
+	reason, s, err := run(t, context.Background(), "exit 3", exitcond.Conditions{UntilFailure: true})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if reason != exitcond.Failed {
+		t.Errorf("reason=%v want %v", reason, exitcond.Failed)
+	}
+	if s.History.At(0).ExitCode != 3 {
+		t.Errorf("ExitCode=%d want 3", s.History.At(0).ExitCode)
+	}
+}
+
+// A cancelled context ends the loop with ctx.Err() even when no condition ever fires.
+func TestRunStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	reason, _, err := run(t, ctx, "echo same", exitcond.Conditions{OnChange: true})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err=%v want context.DeadlineExceeded", err)
+	}
+	if reason != exitcond.None {
+		t.Errorf("reason=%v want None", reason)
+	}
+}
+
+// Precise ticks faster than the command runs still produce frames in start order, under
+// every overlap policy.
+func TestRunPreciseOverlap(t *testing.T) {
+	for _, policy := range []schedule.Policy{schedule.Skip, schedule.Queue, schedule.Concurrent} {
+		command := "date +%s%N; sleep 0.03"
+		s := session.NewSession(command, 10*time.Millisecond)
+		cfg := Config{Interval: 10 * time.Millisecond, Precise: true, Overlap: policy, Exit: exitcond.Conditions{OnChange: true}}
+		reason, err := Run(context.Background(), runner.New(command), s, cfg)
+		if err != nil || reason != exitcond.Changed {
+			t.Fatalf("policy %v: reason=%v err=%v", policy, reason, err)
+		}
+		if s.History.Len() != 2 || s.History.At(1).Timestamp.Before(s.History.At(0).Timestamp) {
+			t.Errorf("policy %v: want two frames in start order, got %d", policy, s.History.Len())
+		}
+	}
+}
+
+type fakeTrigger chan string
+
+func (f fakeTrigger) Events() <-chan string { return f }
+func (f fakeTrigger) Close() error          { return nil }
+
+// With a trigger and no poll interval, only the first run and the trigger's events run the
+// command.
+func TestRunOnTrigger(t *testing.T) {
+	trig := make(fakeTrigger)
+	command := "date +%s%N"
+	s := session.NewSession(command, 0)
+	done := make(chan struct{})
+	var reason exitcond.Reason
+	var err error
+	go func() {
+		reason, err = Run(context.Background(), runner.New(command), s, Config{
+			Trigger: trig, Overlap: schedule.Queue, Exit: exitcond.Conditions{OnChange: true},
+		})
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Run returned before the trigger fired")
+	case <-time.After(100 * time.Millisecond):
+	}
+	trig <- "main.go"
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("trigger did not run the command")
+	}
+	if err != nil || reason != exitcond.Changed || s.History.Len() != 2 {
+		t.Errorf("reason=%v err=%v frames=%d, want Changed after two frames", reason, err, s.History.Len())
+	}
+}
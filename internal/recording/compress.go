@@ -0,0 +1,136 @@
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Compressed recordings are gzip streams. A path ending in ".gz" asks for one; Flow.Compress
+// (-compress) asks regardless of the name. Load recognises them by their magic bytes, not
+// their name. zstd would compress better but needs a third-party codec this build does not
+// carry, so a ".zst" path and a zstd-compressed file are refused rather than mis-read.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ErrZstd is returned for a ".zst" recording path and when loading a zstd-compressed file.
+var ErrZstd = errors.New("recording: zstd compression is not supported by this build (use .gz)")
+
+// checkCompression rejects a path whose extension asks for a compression this build cannot
+// write.
+func checkCompression(path string) error {
+	if filepath.Ext(path) == ".zst" {
+		return ErrZstd
+	}
+	return nil
+}
+
+// fileWriter writes JSON values one per line to a recording file opened with O_EXCL,
+// through gzip when compressing. Every value is flushed through the compressor as it is
+// written, so a crash leaves a stream that decompresses up to the last whole frame, as an
+// uncompressed file keeps every line written before it. An uncompressed file also gets an
+// index of its frames (index.go), written beside it on close.
+type fileWriter struct {
+	path  string
+	f     *os.File
+	gz    *gzip.Writer // nil when not compressing
+	enc   *json.Encoder
+	size  int64        // bytes written so far, uncompressed only
+	index []indexEntry // frames written so far, uncompressed only
+}
+
+func newFileWriter(path string, compress bool) (*fileWriter, error) {
+	if err := checkCompression(path); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	w := &fileWriter{path: path, f: f}
+	var out io.Writer = countingWriter{f, &w.size}
+	if compress || filepath.Ext(path) == ".gz" {
+		w.gz = gzip.NewWriter(f)
+		out = w.gz
+	}
+	w.enc = json.NewEncoder(out)
+	w.enc.SetEscapeHTML(false)
+	return w, nil
+}
+
+// write encodes v as one line and pushes it to the file.
+func (w *fileWriter) write(v any) error {
+	if err := w.enc.Encode(v); err != nil {
+		return err
+	}
+	if w.gz != nil {
+		return w.gz.Flush()
+	}
+	return nil
+}
+
+// writeFrame writes f and, uncompressed, notes where it landed for the index.
+func (w *fileWriter) writeFrame(f Frame) error {
+	off := w.size
+	if err := w.write(f); err != nil {
+		return err
+	}
+	if w.gz == nil {
+		w.index = append(w.index, indexEntry{
+			Off: off, Len: int(w.size - off), Ts: f.Ts, Stream: f.Stream, Key: f.Delta == nil,
+		})
+	}
+	return nil
+}
+
+// close finishes the compressed stream, if any, and closes the file. An uncompressed
+// file's index is written beside it; failing to is not an error, since the index is only a
+// shortcut and Load does without it.
+func (w *fileWriter) close() error {
+	var err error
+	if w.gz != nil {
+		err = w.gz.Close()
+	}
+	err = errors.Join(err, w.f.Close())
+	if w.gz == nil && err == nil {
+		_ = writeIndex(w.path, w.size, w.index)
+	}
+	return err
+}
+
+// abort closes the file and removes it, leaving no index.
+func (w *fileWriter) abort() {
+	_ = w.f.Close()
+	_ = os.Remove(w.path)
+}
+
+// countingWriter adds the bytes it passes on to *n.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += int64(n)
+	return n, err
+}
+
+// decompressed returns a reader of r's content, unwrapping gzip when its magic bytes say so.
+func decompressed(r *bufio.Reader) (io.Reader, error) {
+	magic, _ := r.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(r)
+	case bytes.HasPrefix(magic, zstdMagic):
+		return nil, ErrZstd
+	}
+	return r, nil
+}
@@ -0,0 +1,106 @@
+package recording
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// A ".gz" path, or Flow.Compress with any name, writes a gzip stream that Load recognises by
+// its magic bytes.
+func TestRecordingCompressedRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	for _, c := range []struct {
+		name     string
+		compress bool
+	}{{"wch.jsonl.gz", false}, {"wch.jsonl", true}} {
+		path := filepath.Join(dir, c.name)
+		s := session.NewSession("kubectl get pods", time.Second)
+		flow := New(s)
+		flow.Compress = c.compress
+		if err := flow.Start(path); err != nil {
+			t.Fatalf("%s: Start: %v", c.name, err)
+		}
+		for _, e := range podsFrames(20) {
+			mustRecord(t, s, e)
+		}
+		if err := flow.Stop(); err != nil {
+			t.Fatal(err)
+		}
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(raw), string(gzipMagic)) {
+			t.Errorf("%s: not gzip-compressed", c.name)
+		}
+		got, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: Load: %v", c.name, err)
+		}
+		if got.History.Len() != 20 || got.History.At(19).Stdout != s.History.At(19).Stdout {
+			t.Errorf("%s: loaded %d frames", c.name, got.History.Len())
+		}
+	}
+}
+
+// A compressed recording that was never closed (a crash) still loads every frame written
+// before it, with a warning.
+func TestRecordingCompressedCrashTolerant(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wch.jsonl.gz")
+	rec, err := NewJSONLRecorder(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := session.NewSession("x", time.Second)
+	if err := s.StartRecording(rec); err != nil {
+		t.Fatal(err)
+	}
+	mustRecord(t, s, session.Execution{Timestamp: time.Now().UTC(), Stdout: "first\n"})
+	mustRecord(t, s, session.Execution{Timestamp: time.Now().UTC(), Stdout: "second\n"})
+
+	// Snapshot the file as a crash would leave it: no gzip trailer.
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crashed := filepath.Join(t.TempDir(), "crashed.jsonl.gz")
+	if err := os.WriteFile(crashed, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.StopRecording()
+
+	got, err := Load(crashed)
+	if err == nil {
+		t.Errorf("Load should warn about the unterminated stream")
+	}
+	if got == nil || got.History.Len() != 2 || got.History.At(1).Stdout != "second\n" {
+		t.Fatalf("loaded %+v (%v), want both frames", got, err)
+	}
+}
+
+// zstd is refused when writing and when loading, rather than mis-read.
+func TestRecordingZstdRefused(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wch.jsonl.zst")
+	if err := PreflightCheck(path); !errors.Is(err, ErrZstd) {
+		t.Errorf("PreflightCheck(.zst) = %v, want ErrZstd", err)
+	}
+	if _, err := NewJSONLRecorder(path, false); !errors.Is(err, ErrZstd) {
+		t.Errorf("NewJSONLRecorder(.zst) = %v, want ErrZstd", err)
+	}
+
+	zst := filepath.Join(dir, "zstd.jsonl")
+	if err := os.WriteFile(zst, append(zstdMagic, 0, 0, 0, 0), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(zst); !errors.Is(err, ErrZstd) {
+		t.Errorf("Load(zstd) = %v, want ErrZstd", err)
+	}
+}
@@ -0,0 +1,171 @@
+package recording
+
+import (
+	"errors"
+	"strings"
+)
+
+// keyframeInterval is how many frames of a stream may follow a keyframe as deltas before
+// the next full one. It bounds what a corrupt line costs: the frames after it cannot be
+// rebuilt until the next keyframe.
+const keyframeInterval = 100
+
+// maxDeltaCells caps the line-matching table of a delta (changed lines of the old frame
+// times those of the new). Past it the changed block is stored as replaced outright.
+const maxDeltaCells = 4_000_000
+
+// Edit is one step of a line delta (version 2): copy the next Keep lines of the previous
+// frame's stdout, skip Drop more, then add Insert. Lines are the stdout split at "\n", so a
+// trailing newline is an empty last line.
+type Edit struct {
+	Keep   int      `json:"k,omitempty"`
+	Drop   int      `json:"d,omitempty"`
+	Insert []string `json:"i,omitempty"`
+}
+
+// deltaEncoder stores one stream's stdouts as line deltas against the previous frame, with
+// a full keyframe first, every keyframeInterval frames, and whenever a delta would not be
+// smaller.
+type deltaEncoder struct {
+	prev     []string // lines of the previous frame's stdout; nil before the first
+	sinceKey int      // frames written since the last keyframe
+}
+
+// encode replaces f.Stdout with a delta when that pays off.
+func (e *deltaEncoder) encode(f *Frame) {
+	lines := strings.Split(f.Stdout, "\n")
+	prev := e.prev
+	e.prev = lines
+	if prev == nil || e.sinceKey+1 >= keyframeInterval {
+		e.sinceKey = 0
+		return
+	}
+	edits, size := lineDelta(prev, lines)
+	if size >= len(f.Stdout) {
+		e.sinceKey = 0
+		return
+	}
+	e.sinceKey++
+	f.Stdout, f.Delta = "", edits
+}
+
+// lineDelta returns the edits that turn old into new, and roughly how many bytes the lines
+// they insert take. Lines common to both ends are kept; between them, lines are matched as a
+// longest common subsequence.
+func lineDelta(old, new []string) ([]Edit, int) {
+	pre := 0
+	for pre < len(old) && pre < len(new) && old[pre] == new[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(old)-pre && suf < len(new)-pre && old[len(old)-1-suf] == new[len(new)-1-suf] {
+		suf++
+	}
+	var edits []Edit
+	size := 0
+	add := func(keep, drop int, insert []string) {
+		if keep == 0 && drop == 0 && len(insert) == 0 {
+			return
+		}
+		for _, l := range insert {
+			size += len(l) + 1
+		}
+		edits = append(edits, Edit{Keep: keep, Drop: drop, Insert: insert})
+	}
+	keep := pre
+	om, nm := old[pre:len(old)-suf], new[pre:len(new)-suf]
+	oi, nj := 0, 0
+	for _, p := range matchLines(om, nm) {
+		if p[0] > oi || p[1] > nj {
+			add(keep, p[0]-oi, nm[nj:p[1]])
+			keep = 0
+		}
+		keep++
+		oi, nj = p[0]+1, p[1]+1
+	}
+	if oi < len(om) || nj < len(nm) {
+		add(keep, len(om)-oi, nm[nj:])
+		keep = 0
+	}
+	add(keep+suf, 0, nil)
+	return edits, size
+}
+
+// matchLines returns the index pairs of a longest common subsequence of equal lines, or none
+// when the table would exceed maxDeltaCells.
+func matchLines(old, new []string) [][2]int {
+	if len(old) == 0 || len(new) == 0 || len(old)*len(new) > maxDeltaCells {
+		return nil
+	}
+	// dp[i][j] is the LCS length of old[i:] and new[j:], so the walk below runs forward.
+	w := len(new) + 1
+	dp := make([]int32, (len(old)+1)*w)
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				dp[i*w+j] = dp[(i+1)*w+j+1] + 1
+			} else {
+				dp[i*w+j] = max(dp[(i+1)*w+j], dp[i*w+j+1])
+			}
+		}
+	}
+	var pairs [][2]int
+	for i, j := 0, 0; i < len(old) && j < len(new); {
+		switch {
+		case old[i] == new[j]:
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		case dp[(i+1)*w+j] >= dp[i*w+j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// errBrokenDelta is returned by applyDelta for a delta with no frame to apply it to, or one
+// that does not fit it; the frame is counted as corrupt.
+var errBrokenDelta = errors.New("recording: delta does not apply")
+
+// deltaDecoder rebuilds one stream's stdouts from keyframes and deltas.
+type deltaDecoder struct {
+	prev []string // nil before the first keyframe, and after a frame that failed to decode
+}
+
+// decode restores f.Stdout from f.Delta, if any.
+func (d *deltaDecoder) decode(f *Frame) error {
+	if f.Delta == nil {
+		d.prev = strings.Split(f.Stdout, "\n")
+		return nil
+	}
+	lines, err := applyDelta(d.prev, f.Delta)
+	if err != nil {
+		d.prev = nil // everything up to the next keyframe is lost with it
+		return err
+	}
+	d.prev = lines
+	f.Stdout, f.Delta = strings.Join(lines, "\n"), nil
+	return nil
+}
+
+func applyDelta(prev []string, edits []Edit) ([]string, error) {
+	if prev == nil {
+		return nil, errBrokenDelta
+	}
+	out := make([]string, 0, len(prev))
+	i := 0
+	for _, e := range edits {
+		if e.Keep < 0 || e.Drop < 0 || i+e.Keep+e.Drop > len(prev) {
+			return nil, errBrokenDelta
+		}
+		out = append(out, prev[i:i+e.Keep]...)
+		i += e.Keep + e.Drop
+		out = append(out, e.Insert...)
+	}
+	if i != len(prev) {
+		return nil, errBrokenDelta
+	}
+	return out, nil
+}
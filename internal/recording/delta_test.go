@@ -0,0 +1,144 @@
+package recording
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// Every delta applies back to the frame it was computed for.
+func TestLineDeltaRoundTrip(t *testing.T) {
+	cases := []struct{ old, new string }{
+		{"a\nb\nc\n", "a\nb\nc\n"},
+		{"a\nb\nc\n", "a\nx\nc\n"},
+		{"a\nb\nc\n", "z\na\nb\nc\n"},
+		{"a\nb\nc\n", "a\nb\nc\nd\n"},
+		{"a\nb\nc\nd\n", "b\nd\n"},
+		{"", "a\n"},
+		{"a\n", ""},
+		{"a\nb\na\nb\n", "b\na\nb\na\n"},
+		{"x", "y"},
+	}
+	for _, c := range cases {
+		old, new := strings.Split(c.old, "\n"), strings.Split(c.new, "\n")
+		edits, _ := lineDelta(old, new)
+		got, err := applyDelta(old, edits)
+		if err != nil || strings.Join(got, "\n") != c.new {
+			t.Errorf("%q -> %q: edits %+v applied to %q (%v)", c.old, c.new, edits, strings.Join(got, "\n"), err)
+		}
+	}
+	if _, err := applyDelta([]string{"a"}, []Edit{{Keep: 2}}); err == nil {
+		t.Errorf("a delta keeping more lines than the frame has must not apply")
+	}
+	if _, err := applyDelta(nil, []Edit{{Keep: 1}}); err == nil {
+		t.Errorf("a delta without a previous frame must not apply")
+	}
+}
+
+// podsFrames is a kubectl-like run where one row's AGE moves each frame.
+func podsFrames(n int) []session.Execution {
+	frames := make([]session.Execution, n)
+	for i := range frames {
+		var b strings.Builder
+		b.WriteString("NAME READY STATUS AGE\n")
+		for p := range 50 {
+			age := "5m"
+			if p == 7 {
+				age = fmt.Sprintf("%ds", i)
+			}
+			fmt.Fprintf(&b, "pod-%02d 1/1 Running %s\n", p, age)
+		}
+		frames[i] = session.Execution{Timestamp: time.Date(2026, 5, 30, 12, 0, 0, 0, time.UTC).Add(time.Duration(i) * time.Second), Stdout: b.String()}
+	}
+	return frames
+}
+
+// A version 2 recording stores mostly deltas, with a keyframe every keyframeInterval frames,
+// and loads back to the same stdouts.
+func TestRecordingDeltaRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wch.jsonl")
+	s := session.NewSession("kubectl get pods", time.Second)
+	mustStartJSONL(t, s, path)
+	frames := podsFrames(keyframeInterval + 5)
+	for _, e := range frames {
+		mustRecord(t, s, e)
+	}
+	if err := s.StopRecording(); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyframes := strings.Count(string(raw), `"stdout"`); keyframes != 2 {
+		t.Errorf("%d keyframes, want 2 (the first and one after %d frames)", keyframes, keyframeInterval)
+	}
+	if full := len(frames) * len(frames[0].Stdout); len(raw) > full/4 {
+		t.Errorf("recording is %d bytes, want well under the %d of full frames", len(raw), full)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.History.Len() != len(frames) {
+		t.Fatalf("History len=%d want %d", got.History.Len(), len(frames))
+	}
+	for i, e := range session.Executions(got.History) {
+		if e.Stdout != frames[i].Stdout {
+			t.Fatalf("frame %d stdout differs:\n%q\nwant\n%q", i, e.Stdout, frames[i].Stdout)
+		}
+	}
+}
+
+// A version 1 recording (every stdout in full) still loads.
+func TestLoadVersion1(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "v1.jsonl")
+	data := `{"format":"wch-history","version":1,"command":"x","interval":"1s"}
+{"ts":"2026-05-30T12:00:00Z","exit":0,"stdout":"a\n"}
+{"ts":"2026-05-30T12:00:01Z","exit":0,"stdout":"b\n"}
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.History.Len() != 2 || got.History.At(1).Stdout != "b\n" {
+		t.Errorf("History=%+v", session.Executions(got.History))
+	}
+}
+
+// A corrupt frame takes the deltas that follow it down with it until the next keyframe; the
+// frames before it and from the keyframe on still load.
+func TestLoadBrokenDeltaChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wch.jsonl")
+	data := `{"format":"wch-history","version":2,"command":"x","interval":"1s"}
+{"ts":"2026-05-30T12:00:00Z","exit":0,"stdout":"a\nb\n"}
+{"ts":"2026-05-30T12:00:01Z","exit":0,"delta":[{"k":1,"d":1,"i":["c"]},{"k":1}]}
+{"ts":"2026-05-30T12:00:02Z","exit":0,"delta":[{"k":1,
+{"ts":"2026-05-30T12:00:03Z","exit":0,"delta":[{"k":2,"i":["e"]}]}
+{"ts":"2026-05-30T12:00:04Z","exit":0,"stdout":"f\n"}
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "skipped 2") {
+		t.Errorf("err=%v, want 2 skipped frames reported", err)
+	}
+	var outs []string
+	for _, e := range session.Executions(got.History) {
+		outs = append(outs, e.Stdout)
+	}
+	if strings.Join(outs, "|") != "a\nb\n|a\nc\n|f\n" {
+		t.Errorf("loaded %q", outs)
+	}
+}
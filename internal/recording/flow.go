@@ -19,9 +19,11 @@ var ErrPathExists = errors.New("recording: path exists")
 // AutoStartRequest signals a desire to begin recording immediately on TUI startup -- the
 // typed alternative to the empty-string-means-no convention. nil means no request.
 // Built by the CLI from the validated `-w` flag value; passed through tui.Config; the TUI
-// fires a deferred message at Init time that calls Flow.Start(Path).
+// fires a deferred message at Init time that calls Flow.Start(Path), or Flow.Attach(Recorder)
+// when Recorder is set.
 type AutoStartRequest struct {
-	Path string
+	Path     string
+	Recorder session.Recorder // non-nil: one stream of a Multiplex already open at Path
 }
 
 // maxSanitizedCommandLen caps the command-derived portion of a default recording filename
@@ -43,11 +45,14 @@ func NormalizePath(input string) (string, error) {
 	return expanded, nil
 }
 
-// PreflightCheck reports whether a recording can be created at path. Today the only
-// failure mode is ErrPathExists -- JSONLRecorder's O_EXCL open is the atomic guard,
-// but the CLI uses PreflightCheck to refuse early (before launching the TUI) with a
-// clear stderr message. Returns nil when the path is available.
+// PreflightCheck reports whether a recording can be created at path: ErrPathExists when
+// something is already there -- JSONLRecorder's O_EXCL open is the atomic guard, but the
+// CLI uses PreflightCheck to refuse early (before launching the TUI) with a clear stderr
+// message -- or ErrZstd for a ".zst" path. Returns nil when the path is available.
 func PreflightCheck(path string) error {
+	if err := checkCompression(path); err != nil {
+		return err
+	}
 	if _, err := os.Stat(path); err == nil {
 		return fmt.Errorf("%w: %s", ErrPathExists, path)
 	}
@@ -96,14 +101,17 @@ func sanitizeCommand(s string) string {
 type Flow struct {
 	session *session.Session
 	factory func(path string) (session.Recorder, error)
+
+	// Compress gzips recordings whatever their path's extension (-compress); a ".gz" path
+	// is compressed either way.
+	Compress bool
 }
 
 // New constructs a Flow that opens JSONL recordings on disk.
 func New(s *session.Session) *Flow {
-	return &Flow{
-		session: s,
-		factory: func(path string) (session.Recorder, error) { return NewJSONLRecorder(path) },
-	}
+	f := &Flow{session: s}
+	f.factory = func(path string) (session.Recorder, error) { return NewJSONLRecorder(path, f.Compress) }
+	return f
 }
 
 // Start opens a recorder at path and arms the session. Returns an error classified via
@@ -121,6 +129,16 @@ func (f *Flow) Start(path string) error {
 		}
 		return err
 	}
+	return f.Attach(rec)
+}
+
+// Attach arms the session with a recorder the caller opened, such as one stream of a
+// Multiplex. The recorder is closed when arming fails.
+func (f *Flow) Attach(rec session.Recorder) error {
+	if f.IsActive() {
+		_ = rec.Close()
+		return errors.New("recording: already in progress")
+	}
 	if err := f.session.StartRecording(rec); err != nil {
 		_ = rec.Close()
 		return err
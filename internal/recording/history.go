@@ -0,0 +1,186 @@
+package recording
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// historyWindow is how many decoded frames a fileHistory keeps in memory.
+const historyWindow = 64
+
+// fileHistory is a session.History over one stream of an indexed recording: frames are read
+// from the file and decoded when asked for, and only the historyWindow nearest the last one
+// asked for stay in memory. A delta frame is rebuilt from the keyframe before it, or from
+// the frame decoded just before it when stepping forward. Executions appended after loading
+// (replay adds none, but the interface allows it) are held in memory.
+type fileHistory struct {
+	f       *os.File
+	entries []indexEntry // this stream's frames, in file order
+	bytes   int64        // their lines' total length
+	extra   []session.Execution
+	window  map[int]session.Execution
+	// The last frame decoded and its stdout lines: the base for the delta after it.
+	last      int
+	lastLines []string
+}
+
+func newFileHistory(path string, entries []indexEntry) (*fileHistory, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	h := &fileHistory{f: f, entries: entries, window: map[int]session.Execution{}, last: -1}
+	for _, e := range entries {
+		h.bytes += int64(e.Len)
+	}
+	return h, nil
+}
+
+func (h *fileHistory) Len() int { return len(h.entries) + len(h.extra) }
+
+func (h *fileHistory) Timestamp(i int) time.Time {
+	if i >= len(h.entries) {
+		return h.extra[i-len(h.entries)].Timestamp
+	}
+	return h.entries[i].Ts
+}
+
+// Meta returns frame i without its outputs: from the window when it is there, else parsed
+// from its line, which a delta frame holds whole but for its output.
+func (h *fileHistory) Meta(i int) session.Execution {
+	if i >= len(h.entries) {
+		e := h.extra[i-len(h.entries)]
+		e.Stdout, e.Stderr = "", ""
+		return e
+	}
+	e, ok := h.window[i]
+	if !ok {
+		frame, err := h.read(i)
+		if err != nil {
+			return session.Execution{Timestamp: h.entries[i].Ts, Error: fmt.Errorf("recording: frame unreadable: %w", err)}
+		}
+		e = executionFrom(frame)
+	}
+	e.Stdout, e.Stderr = "", ""
+	return e
+}
+
+func (h *fileHistory) Append(e session.Execution) {
+	h.extra = append(h.extra, e)
+	h.bytes += int64(len(e.Stdout) + len(e.Stderr))
+}
+
+// Bytes is what the frames take in the file (a delta frame's line, not its whole output),
+// plus the output of any appended since.
+func (h *fileHistory) Bytes() int64 { return h.bytes }
+
+// DropOldest forgets the oldest frame; the window is keyed by index, so it is dropped too.
+func (h *fileHistory) DropOldest() {
+	if len(h.entries) == 0 {
+		h.bytes -= int64(len(h.extra[0].Stdout) + len(h.extra[0].Stderr))
+		h.extra = slices.Delete(h.extra, 0, 1)
+		return
+	}
+	h.bytes -= int64(h.entries[0].Len)
+	h.entries = h.entries[1:]
+	clear(h.window)
+	h.last, h.lastLines = -1, nil
+}
+
+// At returns frame i, decoding it if it is not in the window. A frame that cannot be read
+// or rebuilt comes back as an execution carrying the error, at its timestamp.
+func (h *fileHistory) At(i int) session.Execution {
+	if i >= len(h.entries) {
+		return h.extra[i-len(h.entries)]
+	}
+	if e, ok := h.window[i]; ok {
+		return e
+	}
+	e, err := h.decode(i)
+	if err != nil {
+		e = session.Execution{Timestamp: h.entries[i].Ts, Error: fmt.Errorf("recording: frame unreadable: %w", err)}
+	}
+	h.remember(i, e)
+	return e
+}
+
+// decode rebuilds frame i from the nearest point it can: the last frame decoded when that
+// lies between i and the keyframe before it, else that keyframe.
+func (h *fileHistory) decode(i int) (session.Execution, error) {
+	from := i
+	for from >= 0 && !h.entries[from].Key {
+		from--
+	}
+	var dec deltaDecoder
+	if h.last >= 0 && h.last < i && h.last >= from {
+		from, dec.prev = h.last+1, h.lastLines
+	} else if from < 0 {
+		return session.Execution{}, errBrokenDelta
+	}
+	h.last, h.lastLines = -1, nil
+	var frame Frame
+	for j := from; j <= i; j++ {
+		var err error
+		if frame, err = h.read(j); err != nil {
+			return session.Execution{}, err
+		}
+		if err := dec.decode(&frame); err != nil {
+			return session.Execution{}, err
+		}
+	}
+	h.last, h.lastLines = i, dec.prev
+	return executionFrom(frame), nil
+}
+
+// read reads and parses frame j's line.
+func (h *fileHistory) read(j int) (Frame, error) {
+	e := h.entries[j]
+	buf := make([]byte, e.Len)
+	if _, err := h.f.ReadAt(buf, e.Off); err != nil {
+		return Frame{}, err
+	}
+	var frame Frame
+	if err := json.Unmarshal(buf, &frame); err != nil {
+		return Frame{}, err
+	}
+	return frame, nil
+}
+
+// remember puts frame i in the window, evicting the frame farthest from it when full.
+func (h *fileHistory) remember(i int, e session.Execution) {
+	if len(h.window) >= historyWindow {
+		far, dist := -1, -1
+		for k := range h.window {
+			if d := abs(k - i); d > dist {
+				far, dist = k, d
+			}
+		}
+		delete(h.window, far)
+	}
+	h.window[i] = e
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Close closes the recording. Idempotent.
+func (h *fileHistory) Close() error {
+	if h.f == nil {
+		return nil
+	}
+	err := h.f.Close()
+	h.f = nil
+	return err
+}
+
+// Compile-time guarantee that fileHistory satisfies session.History.
+var _ session.History = (*fileHistory)(nil)
@@ -0,0 +1,127 @@
+package recording
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+)
+
+// An uncompressed recording can be replayed without decoding it whole: its index says where
+// each frame's line starts and when the frame was taken, so a fileHistory reads frames on
+// demand. JSONLRecorder and Multiplex write the index beside the recording on close, as
+// "<path>.idx". A recording without one (cut short by a crash, or made before the index
+// existed) is indexed by a scan when it is at least lazyThreshold bytes; a smaller one is
+// simply loaded. A gzipped recording cannot be read from the middle, so it is always
+// loaded whole.
+
+// IndexFormatTag identifies a recording index; IndexVersion is the one this build writes
+// and reads.
+const (
+	IndexFormatTag = "wch-index"
+	IndexVersion   = 1
+)
+
+// lazyThreshold is the size from which a recording without an index is scanned for one
+// rather than loaded into memory. A variable so tests can lower it.
+var lazyThreshold int64 = 64 * 1024 * 1024
+
+// indexHeader is the first line of an index.
+type indexHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	// Size is the recording's size when the index was written; an index whose recording
+	// has since changed size does not describe it.
+	Size int64 `json:"size"`
+}
+
+// indexEntry locates one frame of a recording: the byte offset and length of its line,
+// when it was taken, its stream, and whether it carries its stdout in full (a keyframe)
+// rather than as a delta.
+type indexEntry struct {
+	Off    int64     `json:"o"`
+	Len    int       `json:"n"`
+	Ts     time.Time `json:"t"`
+	Stream int       `json:"s,omitempty"`
+	Key    bool      `json:"k,omitempty"`
+}
+
+// indexPath is where the index of the recording at path lives.
+func indexPath(path string) string {
+	return path + ".idx"
+}
+
+// writeIndex writes the index of the recording at path, which is size bytes, replacing any
+// stale one.
+func writeIndex(path string, size int64, entries []indexEntry) error {
+	f, err := os.Create(indexPath(path))
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	err = enc.Encode(indexHeader{Format: IndexFormatTag, Version: IndexVersion, Size: size})
+	for i := 0; err == nil && i < len(entries); i++ {
+		err = enc.Encode(entries[i])
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readIndex reads the index of the recording at path, which is size bytes. ok is false when
+// there is none or it does not describe the recording as it is.
+func readIndex(path string, size int64) (entries []indexEntry, ok bool) {
+	f, err := os.Open(indexPath(path))
+	if err != nil {
+		return nil, false
+	}
+	defer func() { _ = f.Close() }()
+	dec := json.NewDecoder(bufio.NewReader(f))
+	var header indexHeader
+	if err := dec.Decode(&header); err != nil ||
+		header.Format != IndexFormatTag || header.Version != IndexVersion || header.Size != size {
+		return nil, false
+	}
+	for {
+		var e indexEntry
+		err := dec.Decode(&e)
+		if err == io.EOF {
+			return entries, true
+		}
+		if err != nil || e.Off < 0 || e.Len <= 0 || e.Off+int64(e.Len) > size {
+			return nil, false
+		}
+		entries = append(entries, e)
+	}
+}
+
+// scanIndex indexes the frames of a recording by reading it through once, keeping nothing
+// of a frame but its entry. r is positioned after the header line, at offset off. Lines
+// that do not decode are skipped and counted, as Load does.
+func scanIndex(r io.Reader, off int64) (entries []indexEntry, skipped int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var probe struct {
+			Ts     time.Time       `json:"ts"`
+			Stream int             `json:"stream"`
+			Delta  json.RawMessage `json:"delta"`
+		}
+		if err := json.Unmarshal(line, &probe); err != nil {
+			skipped++
+		} else {
+			entries = append(entries, indexEntry{
+				Off: off, Len: len(line), Ts: probe.Ts, Stream: probe.Stream, Key: probe.Delta == nil,
+			})
+		}
+		off += int64(len(line)) + 1
+	}
+	return entries, skipped, scanner.Err()
+}
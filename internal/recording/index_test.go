@@ -0,0 +1,194 @@
+package recording
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// recordPods writes n podsFrames through a JSONLRecorder and returns them.
+func recordPods(t *testing.T, path string, n int) []session.Execution {
+	t.Helper()
+	s := session.NewSession("kubectl get pods", time.Second)
+	mustStartJSONL(t, s, path)
+	frames := podsFrames(n)
+	for _, e := range frames {
+		mustRecord(t, s, e)
+	}
+	if err := s.StopRecording(); err != nil {
+		t.Fatal(err)
+	}
+	return frames
+}
+
+// lowerLazyThreshold makes every recording without an index large enough to be scanned.
+func lowerLazyThreshold(t *testing.T) {
+	old := lazyThreshold
+	lazyThreshold = 0
+	t.Cleanup(func() { lazyThreshold = old })
+}
+
+// A closed recording leaves an index beside it; Load then reads frames from the file as they
+// are asked for, in any order, keeping no more than historyWindow of them decoded.
+func TestIndexedLoadReadsOnDemand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wch.jsonl")
+	frames := recordPods(t, path, 2*keyframeInterval+10)
+	if _, err := os.Stat(indexPath(path)); err != nil {
+		t.Fatalf("no index written: %v", err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	defer func() { _ = got.History.Close() }()
+	h, ok := got.History.(*fileHistory)
+	if !ok {
+		t.Fatalf("History is %T, want *fileHistory", got.History)
+	}
+	if h.Len() != len(frames) {
+		t.Fatalf("Len=%d want %d", h.Len(), len(frames))
+	}
+	order := []int{len(frames) - 1, keyframeInterval + 3, 0, 5, 4}
+	for i := range frames {
+		order = append(order, i)
+	}
+	for _, i := range order {
+		if !h.Timestamp(i).Equal(frames[i].Timestamp) {
+			t.Fatalf("Timestamp(%d)=%v want %v", i, h.Timestamp(i), frames[i].Timestamp)
+		}
+		if e := h.At(i); e.Stdout != frames[i].Stdout || e.Error != nil {
+			t.Fatalf("At(%d): %v, stdout differs:\n%q\nwant\n%q", i, e.Error, e.Stdout, frames[i].Stdout)
+		}
+		if len(h.window) > historyWindow {
+			t.Fatalf("%d frames decoded, want at most %d", len(h.window), historyWindow)
+		}
+	}
+}
+
+// Without an index, a large enough recording is indexed by a scan that skips corrupt lines,
+// as a full load does; a small one is loaded whole.
+func TestIndexScanWithoutSidecar(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wch.jsonl")
+	frames := recordPods(t, path, 30)
+	if err := os.Remove(indexPath(path)); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"ts":"2026-`); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	small, _ := Load(path)
+	if _, ok := small.History.(*session.MemoryHistory); !ok {
+		t.Errorf("small recording: History is %T, want it loaded whole", small.History)
+	}
+
+	lowerLazyThreshold(t)
+	got, err := Load(path)
+	if err == nil || !strings.Contains(err.Error(), "skipped 1") {
+		t.Errorf("err=%v, want the truncated tail reported", err)
+	}
+	defer func() { _ = got.History.Close() }()
+	if _, ok := got.History.(*fileHistory); !ok {
+		t.Fatalf("History is %T, want *fileHistory", got.History)
+	}
+	if got.History.Len() != len(frames) || got.History.At(17).Stdout != frames[17].Stdout {
+		t.Errorf("scanned %d frames, frame 17 %q", got.History.Len(), got.History.At(17).Stdout)
+	}
+}
+
+// An index written for a recording that has since grown is ignored rather than trusted.
+func TestStaleIndexIgnored(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wch.jsonl")
+	recordPods(t, path, 3)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"ts":"2026-05-30T13:00:00Z","exit":0,"stdout":"late\n"}` + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.History.Len() != 4 || got.History.At(3).Stdout != "late\n" {
+		t.Errorf("loaded %d frames, want the appended one too", got.History.Len())
+	}
+}
+
+// A delta whose keyframe is lost reads back as a frame carrying the error, at its time.
+func TestIndexedBrokenDelta(t *testing.T) {
+	lowerLazyThreshold(t)
+	path := filepath.Join(t.TempDir(), "wch.jsonl")
+	data := `{"format":"wch-history","version":2,"command":"x","interval":"1s"}
+{"ts":"2026-05-30T12:00:00Z","exit":0,"stdout":"a\nb\n
+{"ts":"2026-05-30T12:00:01Z","exit":0,"delta":[{"k":1,"d":1,"i":["c"]},{"k":1}]}
+{"ts":"2026-05-30T12:00:02Z","exit":0,"stdout":"f\n"}
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := Load(path)
+	defer func() { _ = got.History.Close() }()
+	if got.History.Len() != 2 {
+		t.Fatalf("Len=%d want 2", got.History.Len())
+	}
+	if e := got.History.At(0); e.Error == nil || e.Timestamp.Second() != 1 {
+		t.Errorf("broken delta: %+v, want an error at 12:00:01", e)
+	}
+	if e := got.History.At(1); e.Stdout != "f\n" {
+		t.Errorf("keyframe after it: %+v", e)
+	}
+}
+
+// A compressed recording cannot be read from the middle, so it gets no index.
+func TestCompressedRecordingHasNoIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wch.jsonl.gz")
+	recordPods(t, path, 3)
+	if _, err := os.Stat(indexPath(path)); !os.IsNotExist(err) {
+		t.Errorf("index beside a compressed recording: %v", err)
+	}
+}
+
+// Meta reads a frame's metadata from its line alone: a delta frame far from its keyframe
+// comes back without decoding anything.
+func TestIndexedMetaDecodesNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wch.jsonl")
+	s := session.NewSession("kubectl get pods", time.Second)
+	mustStartJSONL(t, s, path)
+	frames := podsFrames(keyframeInterval / 2)
+	for i, e := range frames {
+		e.Duration = time.Duration(i+1) * time.Second
+		mustRecord(t, s, e)
+	}
+	if err := s.StopRecording(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	defer func() { _ = got.History.Close() }()
+	h := got.History.(*fileHistory)
+	i := len(frames) - 1
+	e := h.Meta(i)
+	if e.Duration != time.Duration(i+1)*time.Second || e.Stdout != "" || e.Error != nil {
+		t.Errorf("Meta(%d) = duration %v, stdout %d bytes, error %v", i, e.Duration, len(e.Stdout), e.Error)
+	}
+	if len(h.window) != 0 || h.last >= 0 {
+		t.Errorf("Meta decoded frames: window %d, last %d", len(h.window), h.last)
+	}
+}
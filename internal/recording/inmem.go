@@ -36,19 +36,20 @@ func (r *InMemoryRecorder) FailWriteAfter(n int) {
 	r.writesBefore = n
 }
 
-// Initialize records the header (Command, Interval), resets the in-memory frame slice,
+// Initialize records the header (Command, Argv, Interval), resets the in-memory frame slice,
 // and seeds it with the supplied backlog. The wch JSONL format is described by
 // Header.Format/Version, so those are filled in from the package-level constants.
-func (r *InMemoryRecorder) Initialize(command string, interval time.Duration, backlog []session.Execution) error {
+func (r *InMemoryRecorder) Initialize(command string, argv []string, interval time.Duration, backlog session.History) error {
 	r.header = Header{
 		Format:   FormatTag,
-		Version:  SupportedVersion,
+		Version:  MaxSupportedVersion,
 		Command:  command,
+		Argv:     argv,
 		Interval: interval.String(),
 	}
 	r.frames = r.frames[:0]
-	for _, e := range backlog {
-		r.frames = append(r.frames, frameFrom(e))
+	for i := range backlog.Len() {
+		r.frames = append(r.frames, frameFrom(backlog.At(i)))
 	}
 	return nil
 }
@@ -73,13 +74,14 @@ func (r *InMemoryRecorder) Close() error {
 // and Command are exposed; Format and Version are wch-internal constants.
 type InMemoryHeader struct {
 	Command  string
+	Argv     []string
 	Interval time.Duration
 }
 
 // Header returns the header recorded at Initialize time.
 func (r *InMemoryRecorder) Header() InMemoryHeader {
 	d, _ := time.ParseDuration(r.header.Interval)
-	return InMemoryHeader{Command: r.header.Command, Interval: d}
+	return InMemoryHeader{Command: r.header.Command, Argv: r.header.Argv, Interval: d}
 }
 
 // Frames returns a defensive copy of every frame captured (backlog + WriteFrame).
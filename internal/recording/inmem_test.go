@@ -14,7 +14,7 @@ func TestInMemoryRecorderInitializeStoresBacklog(t *testing.T) {
 		{Stdout: "a\n"},
 		{Stdout: "b\n"},
 	}
-	if err := r.Initialize("cmd", time.Second, backlog); err != nil {
+	if err := r.Initialize("cmd", nil, time.Second, session.NewMemoryHistory(backlog...)); err != nil {
 		t.Fatalf("Initialize: %v", err)
 	}
 	if got := r.Frames(); len(got) != 2 || got[0].Stdout != "a\n" || got[1].Stdout != "b\n" {
@@ -28,7 +28,7 @@ func TestInMemoryRecorderInitializeStoresBacklog(t *testing.T) {
 
 func TestInMemoryRecorderWriteFrameAppends(t *testing.T) {
 	r := NewInMemoryRecorder()
-	_ = r.Initialize("x", time.Second, nil)
+	_ = r.Initialize("x", nil, time.Second, session.NewMemoryHistory())
 	if err := r.WriteFrame(session.Execution{Stdout: "c\n"}); err != nil {
 		t.Fatalf("WriteFrame: %v", err)
 	}
@@ -39,7 +39,7 @@ func TestInMemoryRecorderWriteFrameAppends(t *testing.T) {
 
 func TestInMemoryRecorderCloseIdempotent(t *testing.T) {
 	r := NewInMemoryRecorder()
-	_ = r.Initialize("x", time.Second, nil)
+	_ = r.Initialize("x", nil, time.Second, session.NewMemoryHistory())
 	if err := r.Close(); err != nil {
 		t.Errorf("first Close: %v", err)
 	}
@@ -54,7 +54,7 @@ func TestInMemoryRecorderCloseIdempotent(t *testing.T) {
 func TestInMemoryRecorderFailWriteAfter(t *testing.T) {
 	r := NewInMemoryRecorder()
 	r.FailWriteAfter(1)
-	_ = r.Initialize("x", time.Second, nil)
+	_ = r.Initialize("x", nil, time.Second, session.NewMemoryHistory())
 	if err := r.WriteFrame(session.Execution{Stdout: "ok\n"}); err != nil {
 		t.Fatalf("first WriteFrame should succeed: %v", err)
 	}
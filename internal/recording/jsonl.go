@@ -1,48 +1,47 @@
 package recording
 
 import (
-	"encoding/json"
-	"os"
 	"time"
 
 	"github.com/ivoronin/wch/internal/session"
 )
 
 // JSONLRecorder is the on-disk session.Recorder: one JSON value per line, header first.
-// The file is opened with O_EXCL — refusing to clobber an existing recording.
+// The file is opened with O_EXCL — refusing to clobber an existing recording. Frames are
+// written in the newest format version, stdout delta-encoded against the previous frame.
+// The file is a gzip stream under compress or a ".gz" path.
 type JSONLRecorder struct {
-	path string
-	f    *os.File
-	enc  *json.Encoder
+	path  string
+	w     *fileWriter // nil once closed
+	delta deltaEncoder
 }
 
 // NewJSONLRecorder opens path with O_EXCL, ready for Initialize to be called next.
 // On os.ErrExist the caller (Flow.Start) is responsible for the user-facing message.
-func NewJSONLRecorder(path string) (*JSONLRecorder, error) {
-	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+func NewJSONLRecorder(path string, compress bool) (*JSONLRecorder, error) {
+	w, err := newFileWriter(path, compress)
 	if err != nil {
 		return nil, err
 	}
-	enc := json.NewEncoder(f)
-	enc.SetEscapeHTML(false)
-	return &JSONLRecorder{path: path, f: f, enc: enc}, nil
+	return &JSONLRecorder{path: path, w: w}, nil
 }
 
 // Initialize writes the header followed by every backlog frame. On any write error
 // during initialization the file is closed AND removed — otherwise the orphan would
 // block a same-path retry under O_EXCL until the user manually deletes it.
-func (r *JSONLRecorder) Initialize(command string, interval time.Duration, backlog []session.Execution) error {
-	if err := r.enc.Encode(Header{
+func (r *JSONLRecorder) Initialize(command string, argv []string, interval time.Duration, backlog session.History) error {
+	if err := r.w.write(Header{
 		Format:   FormatTag,
-		Version:  SupportedVersion,
+		Version:  MaxSupportedVersion,
 		Command:  command,
+		Argv:     argv,
 		Interval: interval.String(),
 	}); err != nil {
 		r.abortAndRemove()
 		return err
 	}
-	for _, e := range backlog {
-		if err := r.enc.Encode(frameFrom(e)); err != nil {
+	for i := range backlog.Len() {
+		if err := r.WriteFrame(backlog.At(i)); err != nil {
 			r.abortAndRemove()
 			return err
 		}
@@ -52,16 +51,18 @@ func (r *JSONLRecorder) Initialize(command string, interval time.Duration, backl
 
 // WriteFrame persists one novel execution.
 func (r *JSONLRecorder) WriteFrame(exec session.Execution) error {
-	return r.enc.Encode(frameFrom(exec))
+	frame := frameFrom(exec)
+	r.delta.encode(&frame)
+	return r.w.writeFrame(frame)
 }
 
 // Close releases the file handle. Idempotent.
 func (r *JSONLRecorder) Close() error {
-	if r.f == nil {
+	if r.w == nil {
 		return nil
 	}
-	err := r.f.Close()
-	r.f = nil
+	err := r.w.close()
+	r.w = nil
 	return err
 }
 
@@ -70,12 +71,11 @@ func (r *JSONLRecorder) Close() error {
 // stay stuck on an orphan and so a subsequent Close call from the caller's error path is a
 // clean no-op.
 func (r *JSONLRecorder) abortAndRemove() {
-	if r.f == nil {
+	if r.w == nil {
 		return
 	}
-	_ = r.f.Close()
-	r.f = nil
-	_ = os.Remove(r.path)
+	r.w.abort()
+	r.w = nil
 }
 
 // Compile-time guarantee that JSONLRecorder satisfies session.Recorder.
@@ -17,7 +17,7 @@ import (
 
 func mustStartJSONL(t *testing.T, s *session.Session, path string) *JSONLRecorder {
 	t.Helper()
-	rec, err := NewJSONLRecorder(path)
+	rec, err := NewJSONLRecorder(path, false)
 	if err != nil {
 		t.Fatalf("NewJSONLRecorder: %v", err)
 	}
@@ -71,26 +71,77 @@ func TestRecordingRoundTrip(t *testing.T) {
 	if got.Interval != 5*time.Second {
 		t.Errorf("Interval=%v want %v", got.Interval, 5*time.Second)
 	}
-	if len(got.History) != 2 {
-		t.Fatalf("History len=%d want 2", len(got.History))
+	if got.History.Len() != 2 {
+		t.Fatalf("History len=%d want 2", got.History.Len())
 	}
-	if got.History[0].Stdout != s.History[0].Stdout {
-		t.Errorf("ANSI stdout not preserved: %q vs %q", got.History[0].Stdout, s.History[0].Stdout)
+	if got.History.At(0).Stdout != s.History.At(0).Stdout {
+		t.Errorf("ANSI stdout not preserved: %q vs %q", got.History.At(0).Stdout, s.History.At(0).Stdout)
 	}
-	if got.History[1].Stderr != "warn\n" {
-		t.Errorf("Stderr=%q want %q", got.History[1].Stderr, "warn\n")
+	if got.History.At(1).Stderr != "warn\n" {
+		t.Errorf("Stderr=%q want %q", got.History.At(1).Stderr, "warn\n")
 	}
-	if got.History[1].ExitCode != 2 {
-		t.Errorf("ExitCode=%d want 2", got.History[1].ExitCode)
+	if got.History.At(1).ExitCode != 2 {
+		t.Errorf("ExitCode=%d want 2", got.History.At(1).ExitCode)
 	}
-	if got.History[1].Error == nil || got.History[1].Error.Error() != "exit status 2" {
-		t.Errorf("Error not preserved: %v", got.History[1].Error)
+	if got.History.At(1).Error == nil || got.History.At(1).Error.Error() != "exit status 2" {
+		t.Errorf("Error not preserved: %v", got.History.At(1).Error)
 	}
 	if got.IsRecording() {
 		t.Errorf("Loaded session must not be armed for recording")
 	}
 }
 
+// An argv command round-trips its argv, with the quoted form as the display command.
+func TestRecordingRoundTripArgv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wch.jsonl")
+
+	argv := []string{"jq", ".items[] | .name", "f.json"}
+	s := session.NewSession("jq '.items[] | .name' f.json", time.Second)
+	s.Argv = argv
+	mustStartJSONL(t, s, path)
+	mustRecord(t, s, session.Execution{Timestamp: time.Now(), Stdout: "a\n"})
+	if err := s.StopRecording(); err != nil {
+		t.Fatalf("StopRecording: %v", err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.Command != s.Command || strings.Join(got.Argv, "|") != strings.Join(argv, "|") || len(got.Argv) != 3 {
+		t.Errorf("Command=%q Argv=%q, want %q %q", got.Command, got.Argv, s.Command, argv)
+	}
+}
+
+// Run cost survives the round trip; a frame without it loads with zero values.
+func TestRecordingRoundTripRunCost(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wch.jsonl")
+
+	s := session.NewSession("x", time.Second)
+	mustStartJSONL(t, s, path)
+	cost := session.Execution{
+		Timestamp: time.Now(), Stdout: "a",
+		Duration: 1500 * time.Millisecond,
+		Usage:    session.Usage{User: 250 * time.Millisecond, Sys: 40 * time.Millisecond, MaxRSS: 38 << 20},
+	}
+	mustRecord(t, s, cost)
+	mustRecord(t, s, session.Execution{Timestamp: time.Now(), Stdout: "b"})
+	if err := s.StopRecording(); err != nil {
+		t.Fatalf("StopRecording: %v", err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if h := got.History.At(0); h.Duration != cost.Duration || h.Usage != cost.Usage {
+		t.Errorf("Duration=%v Usage=%+v, want %v %+v", h.Duration, h.Usage, cost.Duration, cost.Usage)
+	}
+	if h := got.History.At(1); h.Duration != 0 || h.Usage != (session.Usage{}) {
+		t.Errorf("frame without cost loaded Duration=%v Usage=%+v", h.Duration, h.Usage)
+	}
+}
+
 // StartRecording dumps every frame already in History.
 func TestRecordingStartDumpsBacklog(t *testing.T) {
 	path := filepath.Join(t.TempDir(), "wch.jsonl")
@@ -111,10 +162,10 @@ func TestRecordingStartDumpsBacklog(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Load: %v", err)
 	}
-	if len(got.History) != 4 {
-		t.Fatalf("backlog dump frames=%d want 4", len(got.History))
+	if got.History.Len() != 4 {
+		t.Fatalf("backlog dump frames=%d want 4", got.History.Len())
 	}
-	for i, f := range got.History {
+	for i, f := range session.Executions(got.History) {
 		wantStdout := fmt.Sprintf("frame %d\n", i)
 		if f.Stdout != wantStdout {
 			t.Errorf("frame %d stdout=%q want %q", i, f.Stdout, wantStdout)
@@ -139,11 +190,11 @@ func TestRecordingLargeFrameRoundTrip(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Load: %v", err)
 	}
-	if len(got.History) != 1 {
-		t.Fatalf("History len=%d want 1", len(got.History))
+	if got.History.Len() != 1 {
+		t.Fatalf("History len=%d want 1", got.History.Len())
 	}
-	if got.History[0].Stdout != big {
-		t.Errorf("large stdout did not round-trip (lens %d vs %d)", len(got.History[0].Stdout), len(big))
+	if got.History.At(0).Stdout != big {
+		t.Errorf("large stdout did not round-trip (lens %d vs %d)", len(got.History.At(0).Stdout), len(big))
 	}
 }
 
@@ -179,8 +230,8 @@ func TestRecordingTruncatedTail(t *testing.T) {
 	if got == nil {
 		t.Fatalf("Load returned nil session despite recoverable error: %v", err)
 	}
-	if len(got.History) != 2 {
-		t.Errorf("History len=%d want 2 (truncated tail should be dropped)", len(got.History))
+	if got.History.Len() != 2 {
+		t.Errorf("History len=%d want 2 (truncated tail should be dropped)", got.History.Len())
 	}
 }
 
@@ -196,7 +247,7 @@ func TestRecordingRefusesExistingFile(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	_, err := NewJSONLRecorder(path)
+	_, err := NewJSONLRecorder(path, false)
 	if !errors.Is(err, os.ErrExist) {
 		t.Fatalf("second NewJSONLRecorder err = %v, want os.ErrExist", err)
 	}
@@ -209,8 +260,8 @@ func TestRecordingRefusesExistingFile(t *testing.T) {
 	if got.Command != "first" {
 		t.Errorf("Command=%q want %q (first recording must survive)", got.Command, "first")
 	}
-	if len(got.History) != 1 || got.History[0].Stdout != "FIRST\n" {
-		t.Errorf("History=%+v want one FIRST frame", got.History)
+	if got.History.Len() != 1 || got.History.At(0).Stdout != "FIRST\n" {
+		t.Errorf("History=%+v want one FIRST frame", session.Executions(got.History))
 	}
 }
 
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"time"
 
@@ -19,60 +20,184 @@ const maxLineSize = 256 * 1024 * 1024
 // format tag and version; mismatch returns a typed error so older binaries fail loud rather
 // than silently mis-parsing a future format.
 //
-// Frame decoding is line-based and recoverable: a single malformed line (mid-stream corruption
+// The file may be gzip-compressed; that is detected from its first bytes. Frame decoding
+// is line-based and recoverable: a single malformed line (mid-stream corruption
 // or a crash-truncated trailing line) is skipped so any fully-decoded frames after it still
 // load. The skipped-line count and any I/O error are surfaced via the returned error so the
 // caller can warn — Load still returns a non-nil Session in that case, signalling "partial
 // load, here's what survived" rather than "load failed".
 //
-// The returned Session is not armed for recording — replay never persists.
+// A multi-stream recording loads as its first stream; LoadStreams reads them all. The
+// returned Session is not armed for recording — replay never persists. An uncompressed
+// recording with an index (index.go) is not read up front: its History reads frames from
+// the file as they are looked at, until the Session is closed.
 func Load(path string) (*session.Session, error) {
+	streams, err := LoadStreams(path)
+	if streams == nil {
+		return nil, err
+	}
+	for _, s := range streams[1:] {
+		_ = s.History.Close()
+	}
+	return streams[0], err
+}
+
+// LoadStreams is Load for a recording of several commands (Multiplex): one Session per
+// stream, in header order. A single-stream recording loads as one. A frame naming a stream
+// the header does not list counts as corrupt, as does a delta frame whose predecessor was
+// lost (every one up to the stream's next keyframe).
+func LoadStreams(path string) ([]*session.Session, error) {
+	if sessions, ok, err := loadIndexed(path); ok {
+		return sessions, err
+	}
 	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = f.Close() }()
 
-	scanner := bufio.NewScanner(f)
+	r, err := decompressed(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
 
+	sessions, err := scanHeader(scanner)
+	if err != nil {
+		return nil, err
+	}
+	decoders := make([]deltaDecoder, len(sessions))
+	histories := make([]*session.MemoryHistory, len(sessions))
+	for i, s := range sessions {
+		histories[i] = session.NewMemoryHistory()
+		s.History = histories[i]
+	}
+	var skipped int
+	for scanner.Scan() {
+		var frame Frame
+		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil || frame.Stream < 0 || frame.Stream >= len(sessions) {
+			skipped++
+			continue
+		}
+		if err := decoders[frame.Stream].decode(&frame); err != nil {
+			skipped++
+			continue
+		}
+		histories[frame.Stream].Append(executionFrom(frame))
+	}
+	if err := scanner.Err(); errors.Is(err, io.ErrUnexpectedEOF) {
+		// A compressed recording cut short (a crash before Close) still holds every frame
+		// flushed before it, as an uncompressed one does.
+		return sessions, errors.New("recording: compressed stream ends early (not closed cleanly)")
+	} else if err != nil {
+		return sessions, fmt.Errorf("recording: read: %w", err)
+	}
+	if skipped > 0 {
+		return sessions, fmt.Errorf("recording: skipped %d corrupt frame(s)", skipped)
+	}
+	return sessions, nil
+}
+
+// loadIndexed is LoadStreams for an uncompressed recording with an index, or one large
+// enough to index by a scan (index.go): each stream's history reads its frames from the
+// file on demand. ok is false when the recording is not one of those, or its header does
+// not read, leaving LoadStreams to load it whole (and report the header's problem).
+func loadIndexed(path string) (sessions []*session.Session, ok bool, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false, nil
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, false, nil
+	}
+	br := bufio.NewReader(f)
+	if r, err := decompressed(br); err != nil || r != io.Reader(br) {
+		return nil, false, nil
+	}
+	header, err := br.ReadBytes('\n')
+	if err != nil {
+		return nil, false, nil
+	}
+	sessions, err = newSessions(header)
+	if err != nil {
+		return nil, false, nil
+	}
+
+	entries, ok := readIndex(path, info.Size())
+	var skipped int
+	if !ok {
+		if info.Size() < lazyThreshold {
+			return nil, false, nil
+		}
+		if entries, skipped, err = scanIndex(br, int64(len(header))); err != nil {
+			return nil, false, nil
+		}
+	}
+	perStream := make([][]indexEntry, len(sessions))
+	for _, e := range entries {
+		if e.Stream < 0 || e.Stream >= len(sessions) {
+			skipped++
+			continue
+		}
+		perStream[e.Stream] = append(perStream[e.Stream], e)
+	}
+	for i, s := range sessions {
+		h, err := newFileHistory(path, perStream[i])
+		if err != nil {
+			for _, s := range sessions[:i] {
+				_ = s.History.Close()
+			}
+			return nil, true, err
+		}
+		s.History = h
+	}
+	if skipped > 0 {
+		return sessions, true, fmt.Errorf("recording: skipped %d corrupt frame(s)", skipped)
+	}
+	return sessions, true, nil
+}
+
+// scanHeader reads and checks the header line, returning an empty Session per stream.
+func scanHeader(scanner *bufio.Scanner) ([]*session.Session, error) {
 	if !scanner.Scan() {
 		if err := scanner.Err(); err != nil {
 			return nil, fmt.Errorf("recording: read header: %w", err)
 		}
 		return nil, errors.New("recording: empty file")
 	}
+	return newSessions(scanner.Bytes())
+}
+
+// newSessions checks a header line and returns an empty Session per stream it lists.
+func newSessions(line []byte) ([]*session.Session, error) {
 	var header Header
-	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+	if err := json.Unmarshal(line, &header); err != nil {
 		return nil, fmt.Errorf("recording: invalid header: %w", err)
 	}
 	if header.Format != FormatTag {
 		return nil, fmt.Errorf("recording: unknown format %q (expected %q)", header.Format, FormatTag)
 	}
-	if header.Version != SupportedVersion {
-		return nil, fmt.Errorf("recording: unsupported version %d (this build supports %d)", header.Version, SupportedVersion)
+	if header.Version < MinSupportedVersion || header.Version > MaxSupportedVersion {
+		return nil, fmt.Errorf("recording: unsupported version %d (this build supports %d to %d)",
+			header.Version, MinSupportedVersion, MaxSupportedVersion)
 	}
-	interval, err := time.ParseDuration(header.Interval)
-	if err != nil {
-		return nil, fmt.Errorf("recording: invalid interval %q: %w", header.Interval, err)
+	streams := header.Streams
+	if len(streams) == 0 {
+		streams = []Stream{{Command: header.Command, Argv: header.Argv, Interval: header.Interval}}
 	}
-	s := session.NewSession(header.Command, interval)
-	var skipped int
-	for scanner.Scan() {
-		var frame Frame
-		if err := json.Unmarshal(scanner.Bytes(), &frame); err != nil {
-			skipped++
-			continue
+	sessions := make([]*session.Session, len(streams))
+	for i, st := range streams {
+		interval, err := time.ParseDuration(st.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("recording: invalid interval %q: %w", st.Interval, err)
 		}
-		s.History = append(s.History, executionFrom(frame))
-	}
-	if err := scanner.Err(); err != nil {
-		return s, fmt.Errorf("recording: read: %w", err)
-	}
-	if skipped > 0 {
-		return s, fmt.Errorf("recording: skipped %d corrupt frame(s)", skipped)
+		sessions[i] = session.NewSession(st.Command, interval)
+		sessions[i].Argv = st.Argv
 	}
-	return s, nil
+	return sessions, nil
 }
 
 // executionFrom converts a Frame back to a session.Execution. The inverse of frameFrom
@@ -88,5 +213,12 @@ func executionFrom(f Frame) session.Execution {
 		Stderr:    f.Stderr,
 		ExitCode:  f.Exit,
 		Error:     err,
+		TimedOut:  f.TimedOut,
+		Duration:  fromMillis(f.DurationMs),
+		Usage: session.Usage{
+			User:   fromMillis(f.UserMs),
+			Sys:    fromMillis(f.SysMs),
+			MaxRSS: f.MaxRSS,
+		},
 	}
 }
@@ -0,0 +1,105 @@
+package recording
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// Multiplex writes several commands' sessions into one recording: a header listing every
+// stream, then each session's frames tagged with its stream index, interleaved in the order
+// they are written. Each session records through its own Recorder(i); the file is closed
+// when the last of them is. Like JSONLRecorder, the file is opened with O_EXCL and gzipped
+// under compress or a ".gz" path.
+type Multiplex struct {
+	path    string
+	w       *fileWriter // nil once closed
+	streams int
+	open    int // stream recorders not yet closed
+}
+
+// NewMultiplex opens path with O_EXCL and writes the header for streams, which must not be
+// empty. On os.ErrExist the caller is responsible for the user-facing message.
+func NewMultiplex(path string, streams []Stream, compress bool) (*Multiplex, error) {
+	if len(streams) == 0 {
+		return nil, errors.New("recording: no streams")
+	}
+	w, err := newFileWriter(path, compress)
+	if err != nil {
+		return nil, err
+	}
+	first := streams[0]
+	if err := w.write(Header{
+		Format:   FormatTag,
+		Version:  MaxSupportedVersion,
+		Command:  first.Command,
+		Argv:     first.Argv,
+		Interval: first.Interval,
+		Streams:  streams,
+	}); err != nil {
+		w.abort()
+		return nil, err
+	}
+	return &Multiplex{path: path, w: w, streams: len(streams), open: len(streams)}, nil
+}
+
+// Recorder returns the session.Recorder for stream i. Each is meant to be armed on one
+// session, once.
+func (m *Multiplex) Recorder(i int) session.Recorder {
+	return &streamRecorder{mux: m, stream: i}
+}
+
+// release closes the file once every stream recorder has been closed.
+func (m *Multiplex) release() error {
+	m.open--
+	if m.open > 0 || m.w == nil {
+		return nil
+	}
+	err := m.w.close()
+	m.w = nil
+	return err
+}
+
+// streamRecorder is one stream of a Multiplex.
+type streamRecorder struct {
+	mux    *Multiplex
+	stream int
+	delta  deltaEncoder // a delta applies to the previous frame of the same stream
+	closed bool
+}
+
+// Initialize writes the backlog frames; the command, argv and interval are already in the
+// multiplex's header.
+func (r *streamRecorder) Initialize(_ string, _ []string, _ time.Duration, backlog session.History) error {
+	for i := range backlog.Len() {
+		if err := r.WriteFrame(backlog.At(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFrame persists one novel execution, tagged with the stream.
+func (r *streamRecorder) WriteFrame(exec session.Execution) error {
+	if r.closed || r.mux.w == nil {
+		return os.ErrClosed
+	}
+	frame := frameFrom(exec)
+	frame.Stream = r.stream
+	r.delta.encode(&frame)
+	return r.mux.w.writeFrame(frame)
+}
+
+// Close releases the stream's share of the file. Idempotent.
+func (r *streamRecorder) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.mux.release()
+}
+
+// Compile-time guarantee that streamRecorder satisfies session.Recorder.
+var _ session.Recorder = (*streamRecorder)(nil)
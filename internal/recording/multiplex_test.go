@@ -0,0 +1,70 @@
+package recording
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// Two sessions recording into one Multiplex load back as two streams, each with its own
+// command and frames; the file closes with the last stream.
+func TestMultiplexRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "multi.wch.jsonl")
+	mux, err := NewMultiplex(path, []Stream{
+		{Command: "kubectl get pods", Interval: "2s"},
+		{Command: "kubectl get events", Argv: []string{"kubectl", "get", "events"}, Interval: "2s"},
+	}, false)
+	if err != nil {
+		t.Fatalf("NewMultiplex: %v", err)
+	}
+	if _, err := NewMultiplex(path, []Stream{{Command: "x", Interval: "1s"}}, false); err == nil {
+		t.Errorf("second NewMultiplex on the same path should fail (O_EXCL)")
+	}
+
+	pods := session.NewSession("kubectl get pods", 2*time.Second)
+	events := session.NewSession("kubectl get events", 2*time.Second)
+	ts := time.Date(2026, 5, 30, 12, 0, 0, 0, time.UTC)
+	mustRecord(t, pods, session.Execution{Timestamp: ts, Stdout: "pod-1\n"})
+	if err := pods.StartRecording(mux.Recorder(0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := events.StartRecording(mux.Recorder(1)); err != nil {
+		t.Fatal(err)
+	}
+	mustRecord(t, events, session.Execution{Timestamp: ts.Add(time.Second), Stdout: "scheduled\n"})
+	mustRecord(t, pods, session.Execution{Timestamp: ts.Add(2 * time.Second), Stdout: "pod-1\npod-2\n"})
+	if err := pods.StopRecording(); err != nil {
+		t.Fatal(err)
+	}
+	if mux.w == nil {
+		t.Fatalf("file closed while a stream is still open")
+	}
+	if err := events.StopRecording(); err != nil {
+		t.Fatal(err)
+	}
+	if mux.w != nil {
+		t.Errorf("file still open after the last stream closed")
+	}
+
+	streams, err := LoadStreams(path)
+	if err != nil {
+		t.Fatalf("LoadStreams: %v", err)
+	}
+	if len(streams) != 2 {
+		t.Fatalf("got %d streams, want 2", len(streams))
+	}
+	if streams[0].Command != "kubectl get pods" || streams[0].History.Len() != 2 {
+		t.Errorf("stream 0: %q with %d frames", streams[0].Command, streams[0].History.Len())
+	}
+	if streams[1].Argv == nil || streams[1].History.Len() != 1 || streams[1].History.At(0).Stdout != "scheduled\n" {
+		t.Errorf("stream 1: argv %v, frames %+v", streams[1].Argv, session.Executions(streams[1].History))
+	}
+
+	// Load reads the first stream alone.
+	s, err := Load(path)
+	if err != nil || s.History.Len() != 2 {
+		t.Errorf("Load: %v, %d frames", err, s.History.Len())
+	}
+}
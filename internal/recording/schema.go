@@ -14,26 +14,61 @@ import (
 // containing this tag.
 const FormatTag = "wch-history"
 
-// SupportedVersion is the only file-format version this build accepts.
-const SupportedVersion = 1
+// The range of file-format versions this build reads; it writes the newest. Version 2 may
+// store a frame's stdout as a line delta against the previous frame of its stream (Frame.
+// Delta); version 1 frames always carry it in full.
+const (
+	MinSupportedVersion = 1
+	MaxSupportedVersion = 2
+)
 
 // Header is the first JSONL line of every wch-history recording.
 type Header struct {
-	Format   string `json:"format"`
-	Version  int    `json:"version"`
-	Command  string `json:"command"`
-	Interval string `json:"interval"`
+	Format   string   `json:"format"`
+	Version  int      `json:"version"`
+	Command  string   `json:"command"`
+	Argv     []string `json:"argv,omitempty"` // set when the command ran without a shell (-x)
+	Interval string   `json:"interval"`
+	// Streams lists every command of a recording several commands were written to together
+	// (Multiplex); Command, Argv and Interval then repeat the first one's. Absent otherwise.
+	Streams []Stream `json:"streams,omitempty"`
+}
+
+// Stream describes one command of a multi-stream recording.
+type Stream struct {
+	Command  string   `json:"command"`
+	Argv     []string `json:"argv,omitempty"`
+	Interval string   `json:"interval"`
 }
 
 // Frame is one captured execution as it sits on disk.
 type Frame struct {
 	Ts     time.Time `json:"ts"`
 	Exit   int       `json:"exit"`
-	Stdout string    `json:"stdout"`
-	Stderr string    `json:"stderr,omitempty"`
-	Error  string    `json:"error,omitempty"`
+	Stdout string    `json:"stdout,omitempty"`
+	// Delta, when set, stands in for Stdout: the edits that turn the previous frame's stdout
+	// into this one's (version 2).
+	Delta  []Edit `json:"delta,omitempty"`
+	Stderr string `json:"stderr,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// TimedOut marks a run killed at -timeout. Absent in recordings made before it existed.
+	TimedOut bool `json:"timed_out,omitempty"`
+	// Run cost: wall-clock duration, CPU time, and peak RSS in bytes. Absent when unknown,
+	// including in recordings made before they were captured.
+	DurationMs float64 `json:"duration_ms,omitempty"`
+	UserMs     float64 `json:"user_ms,omitempty"`
+	SysMs      float64 `json:"sys_ms,omitempty"`
+	MaxRSS     int64   `json:"max_rss,omitempty"`
+	// Stream is the index in Header.Streams of the command that produced the frame; absent
+	// for the first, and in single-stream recordings.
+	Stream int `json:"stream,omitempty"`
 }
 
+// millis converts a duration to the fractional milliseconds the schema stores, and back.
+func millis(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+
+func fromMillis(ms float64) time.Duration { return time.Duration(ms * float64(time.Millisecond)) }
+
 // frameFrom converts a session.Execution to a Frame. The conversion lives in the
 // recording package because session does not know about the on-disk schema; both
 // JSONLRecorder and InMemoryRecorder use it.
@@ -43,10 +78,15 @@ func frameFrom(e session.Execution) Frame {
 		errMsg = e.Error.Error()
 	}
 	return Frame{
-		Ts:     e.Timestamp,
-		Exit:   e.ExitCode,
-		Stdout: e.Stdout,
-		Stderr: e.Stderr,
-		Error:  errMsg,
+		Ts:         e.Timestamp,
+		Exit:       e.ExitCode,
+		Stdout:     e.Stdout,
+		Stderr:     e.Stderr,
+		Error:      errMsg,
+		TimedOut:   e.TimedOut,
+		DurationMs: millis(e.Duration),
+		UserMs:     millis(e.Usage.User),
+		SysMs:      millis(e.Usage.Sys),
+		MaxRSS:     e.Usage.MaxRSS,
 	}
 }
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package runner
+
+import "os/exec"
+
+// killGroupOnCancel leaves exec.CommandContext's default, killing only the process, where
+// there are no Unix process groups.
+func killGroupOnCancel(*exec.Cmd) {}
@@ -0,0 +1,34 @@
+package runner
+
+import (
+	"io"
+	"os/exec"
+	"time"
+)
+
+// ptyDrainTimeout bounds how long runPTY keeps reading after the command exits. The read
+// normally ends at once, when the last holder of the terminal closes it; a background
+// process the command left behind can hold it open indefinitely.
+const ptyDrainTimeout = 100 * time.Millisecond
+
+// runPTY runs cmd with a new pseudo-terminal of the given size as its stdout and stderr
+// (stdin is /dev/null), and returns what it wrote, capped like the piped streams.
+func runPTY(cmd *exec.Cmd, cols, rows int) (string, error) {
+	master, err := startPTY(cmd, cols, rows)
+	if err != nil {
+		return "", err
+	}
+	defer master.Close()
+
+	var out limitedBuffer
+	done := make(chan struct{})
+	go func() {
+		// Ends with EIO once every process has closed the terminal, or at the deadline.
+		_, _ = io.Copy(&out, master)
+		close(done)
+	}()
+	err = cmd.Wait()
+	_ = master.SetReadDeadline(time.Now().Add(ptyDrainTimeout))
+	<-done
+	return out.String(), err
+}
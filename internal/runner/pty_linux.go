@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// PTYSupported reports whether Runner.PTY works on this platform, so -pty can be refused
+// at startup rather than failing every execution.
+const PTYSupported = true
+
+// startPTY opens a pseudo-terminal of the given size, starts cmd as the session leader with
+// the terminal's slave side as its controlling terminal, stdout and stderr, and returns the
+// master side. Output post-processing is off, so lines end in "\n" as through a pipe
+// rather than the terminal's "\r\n".
+func startPTY(cmd *exec.Cmd, cols, rows int) (*os.File, error) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	// Fd would switch the master to blocking mode, and runPTY needs its read deadline.
+	var slave *os.File
+	raw, err := master.SyscallConn()
+	if err == nil {
+		cerr := raw.Control(func(fd uintptr) { slave, err = openSlave(int(fd), cols, rows) })
+		err = errors.Join(err, cerr)
+	}
+	if err != nil {
+		master.Close()
+		return nil, fmt.Errorf("pty: %w", err)
+	}
+	defer slave.Close() // the child holds its own copies
+
+	// Stdin stays /dev/null, as for a piped run: a command that reads it gets EOF instead of
+	// waiting on a terminal nobody types into.
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = nil, slave, slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 1} // stdout
+	if err := cmd.Start(); err != nil {
+		master.Close()
+		return nil, err
+	}
+	return master, nil
+}
+
+// openSlave unlocks and sizes the terminal whose master is fd and opens its slave side.
+func openSlave(fd, cols, rows int) (*os.File, error) {
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		return nil, err
+	}
+	n, err := unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+	if err != nil {
+		return nil, err
+	}
+	ws := &unix.Winsize{Col: uint16(cols), Row: uint16(rows)}
+	if err := unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, ws); err != nil {
+		return nil, err
+	}
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	sfd := int(slave.Fd())
+	t, err := unix.IoctlGetTermios(sfd, unix.TCGETS)
+	if err == nil {
+		t.Oflag &^= unix.OPOST
+		err = unix.IoctlSetTermios(sfd, unix.TCSETS, t)
+	}
+	if err != nil {
+		slave.Close()
+		return nil, err
+	}
+	return slave, nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package runner
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// PTYSupported reports whether Runner.PTY works on this platform, so -pty can be refused
+// at startup rather than failing every execution.
+const PTYSupported = false
+
+// startPTY is only implemented on Linux; elsewhere a Runner with PTY set fails every
+// execution with this error.
+func startPTY(*exec.Cmd, int, int) (*os.File, error) {
+	return nil, errors.New("pty: not supported on this platform")
+}
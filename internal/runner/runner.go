@@ -3,7 +3,12 @@ package runner
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ivoronin/wch/internal/session"
@@ -11,9 +16,46 @@ import (
 
 const errorExitCode = -1 // Used when error is not an ExitError
 
+// waitDelay is how long Execute waits, under a Timeout, for the output pipes to close once
+// the command has exited or been killed.
+const waitDelay = time.Second
+
+// ErrTimeout is wrapped by the error of an execution that ran past Runner.Timeout.
+var ErrTimeout = errors.New("timed out")
+
 // Runner executes commands
 type Runner struct {
 	command string
+	argv    []string // non-nil: run argv[0] directly with these arguments instead of command
+	// Timeout, when positive, bounds each execution: the command's whole process group is
+	// killed when it runs out and the result is a timeout (TimedOut, ErrTimeout); output a
+	// descendant outside the group still holds open is abandoned waitDelay later. Set
+	// before the first Execute.
+	Timeout time.Duration
+	// Shell is the interpreter that runs command as `Shell -c command` (bash, zsh, fish);
+	// "" is sh. Unused for an argv runner. Set before the first Execute.
+	Shell string
+	// PTY runs the command under a pseudo-terminal instead of pipes, so tools that check
+	// isatty keep their colour and layout. Stdout and stderr share the terminal and are
+	// both captured as Stdout. Set before the first Execute.
+	PTY bool
+	// Stream keeps one invocation of a command that never exits (kubectl get -w, tail -f)
+	// running across Execute calls, each of which returns a snapshot of its output so far
+	// (stdout and stderr together, as Stdout) rather than waiting for it to finish. Once
+	// a snapshot has reported the command's exit, the next Execute starts it again. Close
+	// stops it. Set before the first Execute.
+	Stream bool
+	// StreamLines limits a stream's snapshots to its last lines; 0 keeps all of them (up
+	// to the capture cap, beyond which the oldest are dropped).
+	StreamLines int
+	// OnOutput, when set, is called whenever a streaming command completes a line or
+	// exits, from the goroutine reading it.
+	OnOutput func()
+
+	cols, rows atomic.Int32 // PTY size from SetSize; zero until the first call
+
+	mu     sync.Mutex
+	stream *stream // the running invocation under Stream
 }
 
 // New creates a new runner
@@ -23,6 +65,87 @@ func New(command string) *Runner {
 	}
 }
 
+// NewArgv creates a runner that executes argv as is, without a shell: arguments holding
+// spaces, quotes, or globs reach the program exactly as given. argv must not be empty.
+func NewArgv(argv []string) *Runner {
+	return &Runner{
+		command: Quote(argv),
+		argv:    argv,
+	}
+}
+
+// Quote renders argv as a POSIX shell command line that parses back to argv, single-quoting
+// every argument that holds anything but plain word characters. Used to display and record
+// an argv runner's command.
+func Quote(argv []string) string {
+	words := make([]string, len(argv))
+	for i, a := range argv {
+		if a != "" && strings.Trim(a, plainWordChars) == "" {
+			words[i] = a
+			continue
+		}
+		words[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+	}
+	return strings.Join(words, " ")
+}
+
+// SplitWords splits a command line into arguments the way a POSIX shell would without
+// expanding anything: words are separated by blanks, single quotes keep everything up to the
+// next one, double quotes keep everything but a backslash before \, ", $ or `, and a
+// backslash outside quotes escapes the next character. It parses Quote's output back to its
+// argv, and rejects an unterminated quote or a trailing backslash.
+func SplitWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+			continue
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte("\\\"$`", line[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(line[i])
+			}
+			if i == len(line) {
+				return nil, errors.New("unterminated double quote")
+			}
+		case c == '\\':
+			if i+1 == len(line) {
+				return nil, errors.New("trailing backslash")
+			}
+			i++
+			word.WriteByte(line[i])
+		default:
+			word.WriteByte(c)
+		}
+		inWord = true
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// plainWordChars are the characters an argument may consist of to be shown unquoted.
+const plainWordChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-"
+
 // maxOutputBytes caps the captured stdout/stderr per stream so a runaway streaming command
 // (e.g. `journalctl -f`, `cat /dev/urandom`) cannot exhaust memory through the history.
 // 4 MB comfortably absorbs typical kubectl/ps/etc. output while bounding worst-case usage.
@@ -46,29 +169,104 @@ func (l *limitedBuffer) Write(p []byte) (int, error) {
 	return len(p), nil
 }
 
-// Execute runs the command and returns the result. Timestamp is the start time of the
-// invocation (when wch decided to run the command), not the finish time — finish-time
-// stamps drift further from "what wch did" the slower the command is.
-func (r *Runner) Execute(ctx context.Context) session.Execution {
-	cmd := exec.CommandContext(ctx, "sh", "-c", r.command)
+// defaultCols / defaultRows size the PTY until SetSize is called (headless mode never is).
+const (
+	defaultCols = 80
+	defaultRows = 24
+)
 
-	var stdout, stderr limitedBuffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// SetSize sets the PTY size for the following executions, typically the viewport's. Safe to
+// call while a command is running; the running command keeps its size.
+func (r *Runner) SetSize(cols, rows int) {
+	r.cols.Store(int32(cols))
+	r.rows.Store(int32(rows))
+}
+
+func (r *Runner) size() (cols, rows int) {
+	cols, rows = int(r.cols.Load()), int(r.rows.Load())
+	if cols <= 0 || rows <= 0 {
+		return defaultCols, defaultRows
+	}
+	return cols, rows
+}
+
+// Execute runs the command and returns the result. A program that cannot be started (an
+// argv runner's missing binary) is reported like a failed run: Error set, ExitCode -1, as
+// is one killed at the Timeout, which also sets TimedOut. Cancelling ctx kills the
+// command's whole process group, so a hung pipeline's other members die with the shell.
+// Timestamp is the start time of the invocation (when wch decided to run the command), not
+// the finish time — finish-time stamps drift further from "what wch did" the slower the
+// command is; Duration and Usage say how long and how heavy the run was.
+func (r *Runner) Execute(ctx context.Context) session.Execution {
+	if r.Stream {
+		return r.snapshot()
+	}
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	cmd := r.cmd(ctx)
+	killGroupOnCancel(cmd)
+	if r.Timeout > 0 {
+		// A descendant that left the process group (setsid, a daemon) survives the kill and
+		// can keep the output pipes open; stop waiting for it that long after the kill.
+		cmd.WaitDelay = waitDelay
+	}
 
 	result := session.Execution{Timestamp: time.Now()}
-	err := cmd.Run()
-	result.Stdout = stdout.String()
-	result.Stderr = stderr.String()
+	var err error
+	if r.PTY {
+		cols, rows := r.size()
+		result.Stdout, err = runPTY(cmd, cols, rows)
+	} else {
+		var stdout, stderr limitedBuffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		err = cmd.Run()
+		result.Stdout = stdout.String()
+		result.Stderr = stderr.String()
+	}
+	result.Duration = time.Since(result.Timestamp)
+	if cmd.ProcessState != nil {
+		result.Usage = usageOf(cmd.ProcessState)
+	}
 
+	if errors.Is(err, exec.ErrWaitDelay) {
+		// The command itself exited cleanly; a descendant it left behind held its output
+		// past waitDelay, and what it would have written is cut off.
+		err = nil
+	}
 	if err != nil {
 		result.Error = err
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			result.ExitCode = exitErr.ExitCode()
-		} else {
+		if r.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// Killed by us, so the signal exit says nothing about the command.
+			result.Error = fmt.Errorf("%w after %s", ErrTimeout, r.Timeout)
+			result.TimedOut = true
 			result.ExitCode = errorExitCode
+		} else {
+			result.ExitCode = exitCode(err)
 		}
 	}
 
 	return result
 }
+
+// exitCode is the exit code of a failed run: the command's own when it exited, else -1.
+func exitCode(err error) int {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exitErr.ExitCode()
+	}
+	return errorExitCode
+}
+
+func (r *Runner) cmd(ctx context.Context) *exec.Cmd {
+	if r.argv != nil {
+		return exec.CommandContext(ctx, r.argv[0], r.argv[1:]...)
+	}
+	shell := r.Shell
+	if shell == "" {
+		shell = "sh"
+	}
+	return exec.CommandContext(ctx, shell, "-c", r.command)
+}
@@ -0,0 +1,160 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// Quote single-quotes only the arguments that need it, and the quoted line parses back to
+// the same argv under sh.
+func TestQuote(t *testing.T) {
+	argv := []string{"jq", ".items[] | .name", "it's", "", "f.json"}
+	want := `jq '.items[] | .name' 'it'\''s' '' f.json`
+	if got := Quote(argv); got != want {
+		t.Fatalf("Quote=%s want %s", got, want)
+	}
+	e := New(`for a in ` + want + `; do printf '[%s]' "$a"; done`).Execute(context.Background())
+	if e.Stdout != "[jq][.items[] | .name][it's][][f.json]" {
+		t.Errorf("sh parsed the quoted line as %q", e.Stdout)
+	}
+}
+
+// SplitWords undoes Quote and the other quoting a hand-written command line uses, and
+// refuses a line whose quoting is unfinished.
+func TestSplitWords(t *testing.T) {
+	argv := []string{"jq", ".items[] | .name", "it's", "", "f.json"}
+	if got, err := SplitWords(Quote(argv)); err != nil || !slices.Equal(got, argv) {
+		t.Errorf("SplitWords(Quote(argv))=%q, %v want %q", got, err, argv)
+	}
+	got, err := SplitWords(`grep  "a b" "say \"hi\" \n" a\ b\$ f`)
+	if want := []string{"grep", "a b", `say "hi" \n`, "a b$", "f"}; err != nil || !slices.Equal(got, want) {
+		t.Errorf("got %q, %v want %q", got, err, want)
+	}
+	for _, line := range []string{`echo 'a`, `echo "a`, `echo a\`} {
+		if _, err := SplitWords(line); err == nil {
+			t.Errorf("SplitWords(%s) accepted", line)
+		}
+	}
+}
+
+// An argv runner passes its arguments through untouched; no shell expands the glob.
+func TestExecuteArgv(t *testing.T) {
+	e := NewArgv([]string{"printf", "%s|", "a b", "*"}).Execute(context.Background())
+	if e.Error != nil || e.Stdout != "a b|*|" {
+		t.Errorf("Stdout=%q Error=%v want %q", e.Stdout, e.Error, "a b|*|")
+	}
+	if e.Duration <= 0 || e.Usage.MaxRSS <= 0 {
+		t.Errorf("run cost not captured: Duration=%v Usage=%+v", e.Duration, e.Usage)
+	}
+	e = NewArgv([]string{"wch-no-such-program"}).Execute(context.Background())
+	if e.Error == nil || e.ExitCode != errorExitCode {
+		t.Errorf("missing program: ExitCode=%d Error=%v", e.ExitCode, e.Error)
+	}
+}
+
+// A run past the timeout is killed with its whole pipeline, not just the shell, and reports
+// a timeout rather than the signal exit.
+func TestExecuteTimeout(t *testing.T) {
+	r := New("echo started; sleep 10 | cat")
+	r.Timeout = 100 * time.Millisecond
+	start := time.Now()
+	e := r.Execute(context.Background())
+	if took := time.Since(start); took > 5*time.Second {
+		t.Fatalf("Execute took %v: the pipeline outlived the shell", took)
+	}
+	if !e.TimedOut || !errors.Is(e.Error, ErrTimeout) || e.ExitCode != errorExitCode {
+		t.Errorf("TimedOut=%v Error=%v ExitCode=%d", e.TimedOut, e.Error, e.ExitCode)
+	}
+	if e.Stdout != "started\n" {
+		t.Errorf("Stdout=%q, want the output before the kill", e.Stdout)
+	}
+}
+
+// A descendant that escapes the process-group kill (setsid) and keeps stdout open does not
+// hold Execute past the timeout for longer than waitDelay.
+func TestExecuteTimeoutEscapedDescendant(t *testing.T) {
+	if _, err := exec.LookPath("setsid"); err != nil {
+		t.Skip("no setsid")
+	}
+	r := New("echo started; setsid sleep 10")
+	r.Timeout = 100 * time.Millisecond
+	start := time.Now()
+	e := r.Execute(context.Background())
+	if took := time.Since(start); took > r.Timeout+waitDelay+2*time.Second {
+		t.Fatalf("Execute took %v: waited for the escaped descendant", took)
+	}
+	if !e.TimedOut || e.Stdout != "started\n" {
+		t.Errorf("TimedOut=%v Stdout=%q", e.TimedOut, e.Stdout)
+	}
+}
+
+// Under -pty the output goes to a terminal but stdin is still /dev/null, so a command that
+// reads it gets EOF at once instead of blocking on the terminal.
+func TestExecutePTYStdinIsDevNull(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("-pty is Linux-only")
+	}
+	r := New("cat; test -t 1 && echo tty")
+	r.PTY = true
+	r.Timeout = 5 * time.Second
+	e := r.Execute(context.Background())
+	if e.TimedOut || e.Error != nil || e.Stdout != "tty\n" {
+		t.Errorf("TimedOut=%v Error=%v Stdout=%q, want stdin at EOF and stdout a terminal", e.TimedOut, e.Error, e.Stdout)
+	}
+}
+
+// A streaming runner snapshots the running command's last lines, reports its exit once, and
+// starts it again on the next Execute.
+func TestExecuteStream(t *testing.T) {
+	notified := make(chan struct{}, 16)
+	r := New("echo a; echo b; echo c; sleep 0.3; exit 3")
+	r.Stream, r.StreamLines = true, 2
+	r.OnOutput = func() { notified <- struct{}{} }
+	defer r.Close()
+
+	// poll takes snapshots until one satisfies ok.
+	poll := func(ok func(e session.Execution) bool) session.Execution {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			e := r.Execute(context.Background())
+			if ok(e) {
+				return e
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("last snapshot: Stdout=%q Error=%v", e.Stdout, e.Error)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	poll(func(e session.Execution) bool { return e.Stdout == "b\nc\n" && e.Error == nil })
+	select {
+	case <-notified:
+	default:
+		t.Error("no output notification")
+	}
+	e := poll(func(e session.Execution) bool { return e.Error != nil })
+	if e.ExitCode != 3 || e.Stdout != "b\nc\n" {
+		t.Errorf("exit snapshot: ExitCode=%d Stdout=%q", e.ExitCode, e.Stdout)
+	}
+	if e := r.Execute(context.Background()); e.Error != nil || e.Stdout == "b\nc\n" {
+		t.Errorf("after the exit: Stdout=%q Error=%v, want a fresh invocation", e.Stdout, e.Error)
+	}
+}
+
+func TestLineWindow(t *testing.T) {
+	w := lineWindow{max: 2}
+	for _, p := range []string{"on", "e\ntwo\nthr", "ee\n", "fo"} {
+		w.write([]byte(p))
+	}
+	if got := w.String(); got != "two\nthree\nfo" {
+		t.Errorf("window=%q want %q", got, "two\nthree\nfo")
+	}
+}
@@ -0,0 +1,148 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// stream is the one invocation a streaming Runner keeps running (kubectl get -w,
+// journalctl -f, tail -f). Stdout and stderr go into one window of its latest output, of
+// which every Execute takes a snapshot.
+type stream struct {
+	cancel context.CancelFunc
+	done   chan struct{} // closed once the command has exited
+
+	mu       sync.Mutex
+	window   lineWindow
+	onOutput func()
+	state    *os.ProcessState // set on exit, with err
+	err      error
+	reported bool // the exit was in a snapshot; the next Execute starts a new invocation
+}
+
+// Write implements io.Writer for the command's output.
+func (s *stream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	newline := s.window.write(p)
+	s.mu.Unlock()
+	if newline && s.onOutput != nil {
+		s.onOutput()
+	}
+	return len(p), nil
+}
+
+// startStream starts r's command as a stream. The error is a start failure.
+func (r *Runner) startStream() (*stream, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := r.cmd(ctx)
+	killGroupOnCancel(cmd)
+	s := &stream{cancel: cancel, done: make(chan struct{}), window: lineWindow{max: r.StreamLines}, onOutput: r.OnOutput}
+	cmd.Stdout, cmd.Stderr = s, s
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		err := cmd.Wait()
+		s.mu.Lock()
+		s.state, s.err = cmd.ProcessState, err
+		s.mu.Unlock()
+		close(s.done)
+		if s.onOutput != nil {
+			s.onOutput() // so the exit is snapshotted without waiting for a tick
+		}
+	}()
+	return s, nil
+}
+
+// snapshot is Execute for a streaming Runner: the output so far of the running invocation,
+// started on the first call and again on the call after the one that reported its exit.
+// Only a snapshot taken after the exit carries an exit code, error and Usage.
+func (r *Runner) snapshot() session.Execution {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := session.Execution{Timestamp: time.Now()}
+	if r.stream == nil || r.stream.reported {
+		s, err := r.startStream()
+		if err != nil {
+			result.Error, result.ExitCode = err, errorExitCode
+			return result
+		}
+		r.stream = s
+	}
+	s := r.stream
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result.Stdout = s.window.String()
+	if s.state != nil {
+		s.reported = true
+		result.Usage = usageOf(s.state)
+		if s.err != nil {
+			result.Error, result.ExitCode = s.err, exitCode(s.err)
+		}
+	}
+	return result
+}
+
+// Close stops a streaming Runner's invocation, killing its process group. It is a no-op
+// for a Runner that does not stream.
+func (r *Runner) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stream != nil {
+		r.stream.cancel()
+		<-r.stream.done
+		r.stream = nil
+	}
+}
+
+// lineWindow holds a stream's output as its complete lines plus the partial last one. It
+// keeps the last max lines (all when max is 0) and, like the piped streams, no more than
+// maxOutputBytes, dropping the oldest lines first.
+type lineWindow struct {
+	max     int
+	lines   []string
+	size    int // bytes in lines, newlines included
+	partial []byte
+}
+
+// write appends p and reports whether it completed at least one line.
+func (w *lineWindow) write(p []byte) bool {
+	newline := false
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(w.partial) + string(p[:i])
+		w.partial, p = w.partial[:0], p[i+1:]
+		w.lines = append(w.lines, line)
+		w.size += len(line) + 1
+		newline = true
+	}
+	w.partial = append(w.partial, p[:min(len(p), max(0, maxOutputBytes-len(w.partial)))]...)
+	for len(w.lines) > 0 && (w.max > 0 && len(w.lines) > w.max || w.size+len(w.partial) > maxOutputBytes) {
+		w.size -= len(w.lines[0]) + 1
+		w.lines[0] = "" // release it
+		w.lines = w.lines[1:]
+	}
+	return newline
+}
+
+// String returns the window as output: lines terminated by newlines, then the partial line.
+func (w *lineWindow) String() string {
+	var b strings.Builder
+	b.Grow(w.size + len(w.partial))
+	for _, l := range w.lines {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	b.Write(w.partial)
+	return b.String()
+}
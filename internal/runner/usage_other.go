@@ -0,0 +1,14 @@
+//go:build !unix
+
+package runner
+
+import (
+	"os"
+
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// usageOf reads a finished process's CPU times; the peak RSS is not reported here.
+func usageOf(ps *os.ProcessState) session.Usage {
+	return session.Usage{User: ps.UserTime(), Sys: ps.SystemTime()}
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package runner
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// usageOf reads a finished process's CPU times and peak RSS. The shell's figures include
+// the children it waited for, so a pipeline is counted whole; the peak is the largest
+// single process's, not their sum.
+func usageOf(ps *os.ProcessState) session.Usage {
+	u := session.Usage{User: ps.UserTime(), Sys: ps.SystemTime()}
+	if ru, ok := ps.SysUsage().(*syscall.Rusage); ok {
+		u.MaxRSS = int64(ru.Maxrss)
+		if runtime.GOOS != "darwin" && runtime.GOOS != "ios" {
+			u.MaxRSS *= 1024 // kilobytes everywhere but Apple's kernels
+		}
+	}
+	return u
+}
@@ -0,0 +1,44 @@
+package schedule
+
+import "time"
+
+// Feedback is implemented by schedulers that adapt to how runs turn out. Drivers report
+// every recorded result through Observe before asking for the next run.
+type Feedback interface {
+	// Observe reports a run that failed (non-zero exit or no exit at all) or succeeded, and
+	// whether its output differed from the previous frame.
+	Observe(failed, changed bool)
+}
+
+// Backoff is an adaptive interval: it starts at Min, doubles after every failed run and
+// every run whose output did not change, up to Max, and snaps back to Min as soon as a run
+// succeeds after failures or changes the output. A command that is down, or output that has
+// settled, is polled ever less often, and a watch that comes back to life is at full rate
+// again on the next tick.
+type Backoff struct {
+	Min, Max time.Duration
+	cur      time.Duration
+	failing  bool
+}
+
+// NewBackoff returns a Backoff between min and max, starting at min.
+func NewBackoff(min, max time.Duration) *Backoff {
+	return &Backoff{Min: min, Max: max, cur: min}
+}
+
+// Observe implements Feedback.
+func (b *Backoff) Observe(failed, changed bool) {
+	recovered := b.failing && !failed
+	b.failing = failed
+	if recovered || (changed && !failed) {
+		b.cur = b.Min
+		return
+	}
+	b.cur = min(b.Max, 2*b.Interval())
+}
+
+// Interval returns the current effective interval.
+func (b *Backoff) Interval() time.Duration { return max(b.cur, b.Min) }
+
+// Next implements Scheduler.
+func (b *Backoff) Next(now time.Time) time.Time { return now.Add(b.Interval()) }
@@ -0,0 +1,170 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron runs at the local times a five-field cron expression matches (minute, hour, day of
+// month, month, day of week), as crontab(5) reads them: when both day fields are
+// restricted, a day matching either one matches. A day field starting with "*" ("*/2")
+// counts as unrestricted, as in Vixie cron, so "0 0 */2 * 1" is Mondays on odd days.
+type Cron struct {
+	minute, hour, dom, month, dow uint64 // bit n set = value n matches
+	domStar, dowStar              bool   // the day field starts with "*" ("*", "*/2"): unrestricted
+}
+
+// cronField is the value range and names of one field.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // names[i] is value min+i ("jan" is 1); nil = numbers only
+}
+
+var cronFields = [5]cronField{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{"day of week", 0, 7, []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}, // 7 is Sunday too
+}
+
+// cronMacros are the crontab shorthands.
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronHorizon bounds the search for the next match. An expression valid field by field can
+// still never match ("0 0 30 2 *"); ParseCron rejects those, so Next never hits it in use.
+const cronHorizon = 5 * 366 * 24 * time.Hour
+
+// ParseCron parses a cron expression: five fields of "*", numbers, ranges ("9-18"), steps
+// ("*/5", "0-30/10") and comma lists of those, with month and weekday names ("jan",
+// "mon-fri"), or a shorthand such as "@hourly".
+func ParseCron(spec string) (Cron, error) {
+	if expanded, ok := cronMacros[strings.ToLower(spec)]; ok {
+		spec = expanded
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return Cron{}, fmt.Errorf("cron expression %q: want %d fields (minute hour day-of-month month day-of-week), got %d", spec, len(cronFields), len(parts))
+	}
+	var sets [5]uint64
+	for i, p := range parts {
+		set, err := cronFields[i].parse(p)
+		if err != nil {
+			return Cron{}, fmt.Errorf("cron %s: %w", cronFields[i].name, err)
+		}
+		sets[i] = set
+	}
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1 // 7 is Sunday
+	}
+	c := Cron{
+		minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4],
+		domStar: strings.HasPrefix(parts[2], "*"), dowStar: strings.HasPrefix(parts[4], "*"),
+	}
+	if c.Next(time.Now()).IsZero() {
+		return Cron{}, fmt.Errorf("cron expression %q never matches", spec)
+	}
+	return c, nil
+}
+
+// parse parses one field into its bit set.
+func (f cronField) parse(s string) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+			step = n
+		}
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(a); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(b); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max // "5/15" is "5-max/15"
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("empty range %q", rng)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// value parses a number or a name within the field's range.
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("%d out of range %d-%d", n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// Next implements Scheduler: the first matching minute after now, in now's location. It
+// returns the zero Time when nothing matches within cronHorizon.
+func (c Cron) Next(now time.Time) time.Time {
+	loc := now.Location()
+	t := now.Truncate(time.Minute).Add(time.Minute)
+	limit := now.Add(cronHorizon)
+	// Skip a whole month, day or hour at a time while it does not match, so a yearly
+	// expression takes a few dozen steps rather than half a million.
+	for !t.After(limit) {
+		y, mo, d := t.Date()
+		switch {
+		case c.month&(1<<uint(mo)) == 0:
+			t = time.Date(y, mo+1, 1, 0, 0, 0, 0, loc)
+		case !c.dayMatches(t):
+			t = time.Date(y, mo, d+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(y, mo, d, t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if !c.domStar && !c.dowStar {
+		return dom || dow
+	}
+	return dom && dow
+}
@@ -0,0 +1,90 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+)
+
+// Policy says what a precise tick does when the previous run has not finished.
+type Policy int
+
+const (
+	// Skip drops the tick; the run in flight lands late and the next one starts on the
+	// following multiple.
+	Skip Policy = iota
+	// Queue starts one run as soon as the one in flight finishes. Ticks arriving while a
+	// run is already queued are skipped, so a command slower than the interval cannot
+	// build up a backlog.
+	Queue
+	// Concurrent starts another run alongside. A run that finishes after a later-started
+	// one was recorded is dropped as superseded, so history stays in start order.
+	Concurrent
+)
+
+// ParsePolicy parses a -overlap value.
+func ParsePolicy(s string) (Policy, error) {
+	switch s {
+	case "skip":
+		return Skip, nil
+	case "queue":
+		return Queue, nil
+	case "concurrent":
+		return Concurrent, nil
+	}
+	return Skip, fmt.Errorf("unknown overlap policy %q (supported: skip, queue, concurrent)", s)
+}
+
+// Gate tracks the runs in flight and applies a Policy to each tick. The zero value is a
+// Skip gate with nothing running. A driver calls Tick when its schedule fires, Start for
+// every run it launches, and Finish for every result.
+type Gate struct {
+	Policy  Policy
+	running int
+	queued  bool
+	latest  time.Time // start time of the newest recorded run
+	skipped int
+}
+
+// Tick reports whether a tick should start a run now. With nothing in flight it always
+// should; otherwise the Policy decides, and a tick that starts nothing counts as skipped.
+func (g *Gate) Tick() bool {
+	if g.running == 0 {
+		return true
+	}
+	switch {
+	case g.Policy == Concurrent:
+		return true
+	case g.Policy == Queue && !g.queued:
+		g.queued = true
+	default:
+		g.skipped++
+	}
+	return false
+}
+
+// Start records that a run was launched.
+func (g *Gate) Start() { g.running++ }
+
+// Finish records the result of the run that started at start. keep is false for a result
+// superseded by a later-started run that already finished (Concurrent only); such a result
+// counts as skipped. runQueued is true when a Queue'd tick should start its run now.
+func (g *Gate) Finish(start time.Time) (keep, runQueued bool) {
+	g.running = max(0, g.running-1)
+	keep = !start.Before(g.latest)
+	if keep {
+		g.latest = start
+	} else {
+		g.skipped++
+	}
+	if g.queued && g.running == 0 {
+		g.queued = false
+		runQueued = true
+	}
+	return keep, runQueued
+}
+
+// Running returns the number of runs in flight.
+func (g *Gate) Running() int { return g.running }
+
+// Skipped returns how many ticks started no run, plus results dropped as superseded.
+func (g *Gate) Skipped() int { return g.skipped }
@@ -0,0 +1,24 @@
+// Package schedule decides when a watch runs its command next and what happens when a run
+// is still going at that moment. Like exitcond it is consulted by whichever driver runs the
+// watch (the TUI or the headless loop), so both keep the same cadence.
+//
+// A Scheduler says when the next run starts: one interval after now (Every, the default of
+// -i), on wall-clock multiples of it (Aligned), with random jitter (Jittered), or at the
+// times a cron expression matches (Cron). By default drivers ask it once the previous
+// result arrives, so the cadence is the interval plus the run time. Precise scheduling
+// (-precise, like watch -p) instead asks as each tick fires, whatever the runs take; a tick
+// that finds a run still in flight is then resolved by an overlap Policy, tracked by a Gate.
+package schedule
+
+import "time"
+
+// Anchored returns the first wall-clock multiple of interval strictly after now: with a 5s
+// interval, runs land on :00, :05, :10, … of every minute, so two watches of the same thing
+// (or two recordings of it) line up frame for frame. It panics if interval is not positive,
+// as every tick would then be due at once.
+func Anchored(now time.Time, interval time.Duration) time.Time {
+	if interval <= 0 {
+		panic("schedule: non-positive interval for Anchored")
+	}
+	return now.Truncate(interval).Add(interval)
+}
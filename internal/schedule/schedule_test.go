@@ -0,0 +1,186 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnchored(t *testing.T) {
+	at := func(h, m, s, ms int) time.Time {
+		return time.Date(2026, 5, 30, h, m, s, ms*int(time.Millisecond), time.UTC)
+	}
+	tests := []struct {
+		now      time.Time
+		interval time.Duration
+		want     time.Time
+	}{
+		{at(12, 0, 3, 200), 5 * time.Second, at(12, 0, 5, 0)},
+		{at(12, 0, 5, 0), 5 * time.Second, at(12, 0, 10, 0)}, // strictly after
+		{at(12, 0, 59, 999), time.Minute, at(12, 1, 0, 0)},
+		{at(12, 0, 0, 120), 250 * time.Millisecond, at(12, 0, 0, 250)},
+	}
+	for _, tc := range tests {
+		if got := Anchored(tc.now, tc.interval); !got.Equal(tc.want) {
+			t.Errorf("Anchored(%v, %v)=%v want %v", tc.now, tc.interval, got, tc.want)
+		}
+	}
+}
+
+// Wall-clock multiples of a zero or negative interval would all be due at once.
+func TestAnchoredRejectsNonPositiveInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Aligned(%v).Next did not panic", interval)
+				}
+			}()
+			Aligned(interval).Next(time.Now())
+		}()
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	for s, want := range map[string]Policy{"skip": Skip, "queue": Queue, "concurrent": Concurrent} {
+		if got, err := ParsePolicy(s); err != nil || got != want {
+			t.Errorf("ParsePolicy(%q)=%v, %v want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParsePolicy("drop"); err == nil {
+		t.Error("ParsePolicy(drop) should fail")
+	}
+}
+
+// Three ticks while one run is in flight, under each policy.
+func TestGateOverlap(t *testing.T) {
+	t0 := time.Date(2026, 5, 30, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		policy    Policy
+		started   int // runs started by the three overlapping ticks
+		skipped   int
+		runQueued bool
+	}{
+		{Skip, 0, 3, false},
+		{Queue, 0, 2, true},
+		{Concurrent, 3, 0, false},
+	}
+	for _, tc := range tests {
+		g := Gate{Policy: tc.policy}
+		if !g.Tick() {
+			t.Fatalf("%v: an idle gate must start a run", tc.policy)
+		}
+		g.Start()
+		started := 0
+		for range 3 {
+			if g.Tick() {
+				g.Start()
+				started++
+			}
+		}
+		if started != tc.started || g.Skipped() != tc.skipped {
+			t.Errorf("%v: started=%d skipped=%d want %d and %d", tc.policy, started, g.Skipped(), tc.started, tc.skipped)
+		}
+		if keep, runQueued := g.Finish(t0); !keep || runQueued != tc.runQueued {
+			t.Errorf("%v: Finish keep=%v runQueued=%v want true and %v", tc.policy, keep, runQueued, tc.runQueued)
+		}
+	}
+}
+
+// Under Concurrent a run that finishes after a later-started one is dropped as superseded.
+func TestGateDropsSupersededResult(t *testing.T) {
+	t0 := time.Date(2026, 5, 30, 12, 0, 0, 0, time.UTC)
+	g := Gate{Policy: Concurrent}
+	g.Start()
+	g.Start()
+	if keep, _ := g.Finish(t0.Add(time.Second)); !keep {
+		t.Fatal("first result to arrive must be kept")
+	}
+	if keep, _ := g.Finish(t0); keep {
+		t.Error("result of the earlier-started run must be dropped")
+	}
+	if g.Running() != 0 || g.Skipped() != 1 {
+		t.Errorf("running=%d skipped=%d want 0 and 1", g.Running(), g.Skipped())
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		want Scheduler
+	}{
+		{"30s", Every(30 * time.Second)},
+		{"30s±5s", Jittered{30 * time.Second, 5 * time.Second}},
+		{"1m +- 10s", Jittered{time.Minute, 10 * time.Second}},
+	}
+	for _, tc := range tests {
+		if got, err := Parse(tc.spec); err != nil || got != tc.want {
+			t.Errorf("Parse(%q)=%v, %v want %v", tc.spec, got, err, tc.want)
+		}
+	}
+	for _, spec := range []string{"5s±5s", "5s±x", "0s", "* * *", "61 * * * *", "0 0 30 2 *", "*/0 * * * *", "5-1 * * * *"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) should fail", spec)
+		}
+	}
+}
+
+func TestJitteredStaysInBounds(t *testing.T) {
+	j := Jittered{Interval: 10 * time.Second, Jitter: 2 * time.Second}
+	now := time.Now()
+	for range 1000 {
+		if d := j.Next(now).Sub(now); d < 8*time.Second || d > 12*time.Second {
+			t.Fatalf("Next is %v after now, want within 10s±2s", d)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(mo time.Month, d, h, m int) time.Time { return time.Date(2026, mo, d, h, m, 0, 0, time.UTC) }
+	tests := []struct {
+		spec string
+		now  time.Time
+		want time.Time
+	}{
+		{"*/5 * * * *", at(5, 30, 12, 3).Add(20 * time.Second), at(5, 30, 12, 5)},
+		{"*/5 * * * *", at(5, 30, 12, 5), at(5, 30, 12, 10)}, // strictly after
+		{"0 9-18 * * *", at(5, 30, 18, 30), at(5, 31, 9, 0)},
+		{"30 9 * * mon-fri", at(5, 29, 10, 0), at(6, 1, 9, 30)}, // Friday → Monday
+		{"0 0 1 jan *", at(5, 30, 12, 0), time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", at(5, 30, 12, 0), at(5, 30, 13, 0)},
+		{"0 0 13 * 5", at(5, 30, 0, 0), at(6, 5, 0, 0)},   // day-of-month or Friday
+		{"0 0 * * 7", at(5, 30, 12, 0), at(5, 31, 0, 0)},  // 7 is Sunday
+		{"0 0 */2 * 1", at(5, 30, 12, 0), at(6, 1, 0, 0)}, // Mondays on odd days, not 31 May
+	}
+	for _, tc := range tests {
+		c, err := ParseCron(tc.spec)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tc.spec, err)
+		}
+		if got := c.Next(tc.now); !got.Equal(tc.want) {
+			t.Errorf("%q.Next(%v)=%v want %v", tc.spec, tc.now, got, tc.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	b := NewBackoff(time.Second, 5*time.Second)
+	steps := []struct {
+		failed, changed bool
+		want            time.Duration
+	}{
+		{false, true, time.Second},      // a change keeps the base rate
+		{false, false, 2 * time.Second}, // stable output backs off…
+		{false, false, 4 * time.Second},
+		{false, false, 5 * time.Second}, // …up to Max
+		{false, true, time.Second},      // and snaps back on change
+		{true, false, 2 * time.Second},  // failures back off
+		{true, true, 4 * time.Second},   // even when the error output changes
+		{false, false, time.Second},     // the first success snaps back
+	}
+	for i, s := range steps {
+		b.Observe(s.failed, s.changed)
+		if got := b.Interval(); got != s.want {
+			t.Errorf("step %d: Interval=%v want %v", i, got, s.want)
+		}
+	}
+}
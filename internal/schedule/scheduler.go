@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// Scheduler picks when the next run starts. Drivers ask it once per tick: after each result
+// by default, or as each tick fires under -precise.
+type Scheduler interface {
+	// Next returns the start time of the next run after now.
+	Next(now time.Time) time.Time
+}
+
+// Every runs one interval after now: the default cadence of -i.
+type Every time.Duration
+
+// Next implements Scheduler.
+func (e Every) Next(now time.Time) time.Time { return now.Add(time.Duration(e)) }
+
+// Aligned runs on wall-clock multiples of the interval (see Anchored), which must be
+// positive.
+type Aligned time.Duration
+
+// Next implements Scheduler.
+func (a Aligned) Next(now time.Time) time.Time { return Anchored(now, time.Duration(a)) }
+
+// Jittered runs one Interval after now, shifted by a uniformly random offset within ±Jitter,
+// so watches of a shared API started together drift apart instead of polling in lockstep.
+type Jittered struct {
+	Interval time.Duration
+	Jitter   time.Duration
+}
+
+// Next implements Scheduler.
+func (j Jittered) Next(now time.Time) time.Time {
+	offset := rand.N(2*j.Jitter+1) - j.Jitter
+	return now.Add(j.Interval + offset)
+}
+
+// ForInterval returns the scheduler of a plain -i interval: Every, or Aligned when aligned
+// (-precise).
+func ForInterval(interval time.Duration, aligned bool) Scheduler {
+	if aligned {
+		return Aligned(interval)
+	}
+	return Every(interval)
+}
+
+// jitterSeps separate the interval from the jitter in a -schedule value; "+-" is for
+// keyboards without ±.
+var jitterSeps = []string{"±", "+-"}
+
+// Parse parses a -schedule value: an interval with jitter ("30s±5s" or "30s+-5s"), a plain
+// interval ("30s"), or a cron expression ("*/5 * * * *", "@hourly"; see ParseCron).
+func Parse(spec string) (Scheduler, error) {
+	spec = strings.TrimSpace(spec)
+	for _, sep := range jitterSeps {
+		base, jitter, ok := strings.Cut(spec, sep)
+		if !ok {
+			continue
+		}
+		interval, err := time.ParseDuration(strings.TrimSpace(base))
+		if err != nil {
+			return nil, fmt.Errorf("interval: %w", err)
+		}
+		j, err := time.ParseDuration(strings.TrimSpace(jitter))
+		if err != nil {
+			return nil, fmt.Errorf("jitter: %w", err)
+		}
+		if interval <= 0 || j < 0 || j >= interval {
+			return nil, errors.New("jitter must be non-negative and shorter than the interval")
+		}
+		return Jittered{Interval: interval, Jitter: j}, nil
+	}
+	if d, err := time.ParseDuration(spec); err == nil {
+		if d <= 0 {
+			return nil, errors.New("interval must be positive")
+		}
+		return Every(d), nil
+	}
+	return ParseCron(spec)
+}
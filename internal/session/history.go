@@ -0,0 +1,91 @@
+package session
+
+import (
+	"slices"
+	"time"
+)
+
+// History is where a Session keeps its executions, oldest first. A live session keeps
+// them in memory, their lines shared between frames (LineHistory); a replayed recording
+// may instead read them from its file on demand, so only what is being looked at has to
+// be decoded.
+type History interface {
+	// Len is the number of executions held.
+	Len() int
+	// At returns execution i, 0 ≤ i < Len(). A backend that cannot read it back returns
+	// an execution whose Error says why, at the right timestamp.
+	At(i int) Execution
+	// Timestamp returns execution i's timestamp without its output, so a timeline can be
+	// laid out without decoding every frame.
+	Timestamp(i int) time.Time
+	// Meta returns execution i without its outputs (Stdout and Stderr empty), so a status
+	// bar can show how a run went without reading back or rebuilding what it printed.
+	Meta(i int) Execution
+	// Append adds e as the newest execution.
+	Append(e Execution)
+	// DropOldest evicts the oldest execution (MaxHistory and MaxHistoryBytes rotation).
+	DropOldest()
+	// Bytes is what the outputs held take up where the backend keeps them, the measure
+	// MaxHistoryBytes limits.
+	Bytes() int64
+	// Close releases whatever the history holds besides memory. Idempotent.
+	Close() error
+}
+
+// MemoryHistory is the plainest History: every execution in a slice, as loaded.
+type MemoryHistory struct {
+	execs []Execution
+	bytes int64
+}
+
+// NewMemoryHistory returns a History holding execs (which it takes ownership of).
+func NewMemoryHistory(execs ...Execution) *MemoryHistory {
+	h := &MemoryHistory{execs: execs}
+	for _, e := range execs {
+		h.bytes += outputSize(e)
+	}
+	return h
+}
+
+func (h *MemoryHistory) Len() int                  { return len(h.execs) }
+func (h *MemoryHistory) At(i int) Execution        { return h.execs[i] }
+func (h *MemoryHistory) Timestamp(i int) time.Time { return h.execs[i].Timestamp }
+func (h *MemoryHistory) Meta(i int) Execution      { return withoutOutput(h.execs[i]) }
+func (h *MemoryHistory) Bytes() int64              { return h.bytes }
+func (h *MemoryHistory) Close() error              { return nil }
+
+func (h *MemoryHistory) Append(e Execution) {
+	h.execs = append(h.execs, e)
+	h.bytes += outputSize(e)
+}
+
+// DropOldest uses slices.Delete (rather than execs[1:]), which copy-shifts and zeros the
+// freed tail slot, so the evicted execution's stdout/stderr strings are released for GC
+// instead of staying pinned in the backing array until the next slice growth.
+func (h *MemoryHistory) DropOldest() {
+	h.bytes -= outputSize(h.execs[0])
+	h.execs = slices.Delete(h.execs, 0, 1)
+}
+
+// withoutOutput returns e with its outputs dropped.
+func withoutOutput(e Execution) Execution {
+	e.Stdout, e.Stderr = "", ""
+	return e
+}
+
+// outputSize is the size of e's outputs.
+func outputSize(e Execution) int64 {
+	return int64(len(e.Stdout) + len(e.Stderr))
+}
+
+// Executions copies every execution of h into a slice, oldest first.
+func Executions(h History) []Execution {
+	out := make([]Execution, h.Len())
+	for i := range out {
+		out[i] = h.At(i)
+	}
+	return out
+}
+
+// Compile-time guarantee that MemoryHistory satisfies History.
+var _ History = (*MemoryHistory)(nil)
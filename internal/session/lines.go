@@ -0,0 +1,174 @@
+package session
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Rough per-item costs LineHistory.Bytes adds to the text it holds: a distinct line's
+// string header, table slots and map entry; a line reference; an execution's metadata.
+const (
+	lineOverhead  = 64
+	refOverhead   = 4
+	frameOverhead = 160
+)
+
+// LineHistory is the History a Session starts with: it keeps every execution in memory,
+// but its outputs as references into a shared table holding each distinct line once. Frames
+// of a watched command mostly repeat the lines of the one before, so memory grows with what
+// changes rather than with frames × output size. Bytes estimates that memory.
+type LineHistory struct {
+	table  lineTable
+	frames []lineFrame
+	refs   int64 // line references held by frames
+	// The last two executions rebuilt, as the newest is compared on every run and a diff
+	// reads a frame with its predecessor.
+	cache [2]cachedExecution
+}
+
+// lineFrame is one execution of a LineHistory: everything but its outputs, which are the
+// lines out and errs name, split at "\n".
+type lineFrame struct {
+	exec      Execution
+	out, errs []uint32
+}
+
+type cachedExecution struct {
+	i    int // -1 = empty
+	exec Execution
+}
+
+// NewLineHistory returns an empty LineHistory.
+func NewLineHistory() *LineHistory {
+	h := &LineHistory{table: lineTable{ids: map[string]uint32{}}}
+	h.cache[0].i, h.cache[1].i = -1, -1
+	return h
+}
+
+func (h *LineHistory) Len() int                  { return len(h.frames) }
+func (h *LineHistory) Timestamp(i int) time.Time { return h.frames[i].exec.Timestamp }
+func (h *LineHistory) Meta(i int) Execution      { return h.frames[i].exec }
+func (h *LineHistory) Close() error              { return nil }
+
+// Bytes estimates the memory the history takes: each distinct line once, a reference per
+// line of every frame, and each frame's metadata.
+func (h *LineHistory) Bytes() int64 {
+	return h.table.bytes + int64(len(h.table.ids))*lineOverhead +
+		h.refs*refOverhead + int64(len(h.frames))*frameOverhead
+}
+
+func (h *LineHistory) Append(e Execution) {
+	f := lineFrame{exec: e, out: h.table.intern(e.Stdout), errs: h.table.intern(e.Stderr)}
+	f.exec.Stdout, f.exec.Stderr = "", ""
+	h.frames = append(h.frames, f)
+	h.refs += int64(len(f.out) + len(f.errs))
+}
+
+// At rebuilds execution i's outputs from the table, unless it is one of the last two
+// rebuilt.
+func (h *LineHistory) At(i int) Execution {
+	for _, c := range h.cache {
+		if c.i == i {
+			return c.exec
+		}
+	}
+	f := h.frames[i]
+	e := f.exec
+	e.Stdout, e.Stderr = h.table.join(f.out), h.table.join(f.errs)
+	h.cache[1], h.cache[0] = h.cache[0], cachedExecution{i: i, exec: e}
+	return e
+}
+
+// DropOldest evicts the oldest execution, freeing the lines no other frame uses.
+func (h *LineHistory) DropOldest() {
+	f := h.frames[0]
+	h.table.release(f.out)
+	h.table.release(f.errs)
+	h.refs -= int64(len(f.out) + len(f.errs))
+	h.frames = slices.Delete(h.frames, 0, 1)
+	for k := range h.cache {
+		if h.cache[k].i >= 0 {
+			h.cache[k].i--
+		}
+		if h.cache[k].i < 0 {
+			h.cache[k] = cachedExecution{i: -1}
+		}
+	}
+}
+
+// lineTable holds each distinct line once, counting the frames' references to it so a line
+// none uses any more is freed and its id reused.
+type lineTable struct {
+	lines []string
+	refs  []int32
+	ids   map[string]uint32
+	free  []uint32
+	bytes int64 // of the lines held
+}
+
+// intern splits s at "\n" and returns the ids of its lines, adding the new ones.
+func (t *lineTable) intern(s string) []uint32 {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(s, "\n")
+	ids := make([]uint32, len(lines))
+	for i, l := range lines {
+		id, ok := t.ids[l]
+		if !ok {
+			// A copy, so the table does not pin the whole output the line was cut from.
+			l = strings.Clone(l)
+			if n := len(t.free); n > 0 {
+				id, t.free = t.free[n-1], t.free[:n-1]
+				t.lines[id] = l
+			} else {
+				id = uint32(len(t.lines))
+				t.lines = append(t.lines, l)
+				t.refs = append(t.refs, 0)
+			}
+			t.ids[l] = id
+			t.bytes += int64(len(l))
+		}
+		t.refs[id]++
+		ids[i] = id
+	}
+	return ids
+}
+
+// release drops one reference to each of ids, freeing the lines left unused.
+func (t *lineTable) release(ids []uint32) {
+	for _, id := range ids {
+		if t.refs[id]--; t.refs[id] > 0 {
+			continue
+		}
+		l := t.lines[id]
+		delete(t.ids, l)
+		t.bytes -= int64(len(l))
+		t.lines[id] = ""
+		t.free = append(t.free, id)
+	}
+}
+
+// join rebuilds the text ids name.
+func (t *lineTable) join(ids []uint32) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	n := len(ids) - 1
+	for _, id := range ids {
+		n += len(t.lines[id])
+	}
+	var b strings.Builder
+	b.Grow(n)
+	for i, id := range ids {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(t.lines[id])
+	}
+	return b.String()
+}
+
+// Compile-time guarantee that LineHistory satisfies History.
+var _ History = (*LineHistory)(nil)
@@ -0,0 +1,95 @@
+package session_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// podsOutput is a kubectl-like table of n rows where only row i's age differs from frame to
+// frame.
+func podsOutput(n, i int) string {
+	var b strings.Builder
+	b.WriteString("NAME      READY   STATUS    AGE\n")
+	for r := range n {
+		age := "5d"
+		if r == i%n {
+			age = fmt.Sprintf("%ds", i)
+		}
+		fmt.Fprintf(&b, "pod-%04d  1/1     Running   %s\n", r, age)
+	}
+	return b.String()
+}
+
+// A LineHistory gives back every execution as appended, trailing newlines and empty outputs
+// included, in any order and across evictions.
+func TestLineHistoryRoundTrip(t *testing.T) {
+	s := session.NewSession("x", time.Second)
+	s.MaxHistory = 20
+	var want []session.Execution
+	for i := range 50 {
+		e := session.Execution{
+			Timestamp: time.Date(2026, 5, 30, 12, 0, i, 0, time.UTC),
+			Stdout:    podsOutput(10, i),
+			ExitCode:  i % 2,
+		}
+		switch i % 7 {
+		case 3:
+			e.Stdout = ""
+		case 5:
+			e.Stderr = "warning\n\n"
+		}
+		want = append(want, e)
+		_, evicted, _ := s.RecordIfChanged(e)
+		want = want[evicted:]
+	}
+	h := s.History
+	if h.Len() != len(want) {
+		t.Fatalf("Len=%d want %d", h.Len(), len(want))
+	}
+	for _, i := range []int{h.Len() - 1, 0, 7, 6, 7, h.Len() - 2, 3} {
+		got := h.At(i)
+		if got.Stdout != want[i].Stdout || got.Stderr != want[i].Stderr || got.ExitCode != want[i].ExitCode {
+			t.Fatalf("At(%d) differs:\n%q %q\nwant\n%q %q", i, got.Stdout, got.Stderr, want[i].Stdout, want[i].Stderr)
+		}
+		if !h.Timestamp(i).Equal(want[i].Timestamp) {
+			t.Fatalf("Timestamp(%d)=%v want %v", i, h.Timestamp(i), want[i].Timestamp)
+		}
+	}
+}
+
+// Lines repeated across frames are held once, so a history of a mostly static output takes
+// far less than its frames' total size, and evicting frames frees the lines only they used.
+func TestLineHistorySharesLines(t *testing.T) {
+	h := session.NewLineHistory()
+	var total int64
+	for i := range 200 {
+		out := podsOutput(100, i)
+		total += int64(len(out))
+		h.Append(session.Execution{Stdout: out})
+	}
+	if got := h.Bytes(); got <= 0 || got > total/4 {
+		t.Errorf("Bytes=%d for %d bytes of output, want under a quarter", got, total)
+	}
+
+	before := h.Bytes()
+	for range 150 {
+		h.DropOldest()
+	}
+	if got := h.Bytes(); got >= before {
+		t.Errorf("Bytes=%d after evicting 150 frames, was %d", got, before)
+	}
+	for range 50 {
+		h.DropOldest()
+	}
+	if got := h.Bytes(); got != 0 {
+		t.Errorf("Bytes=%d with no frames left, want 0", got)
+	}
+	h.Append(session.Execution{Stdout: "a\nb\n"})
+	if got := h.At(0).Stdout; got != "a\nb\n" {
+		t.Errorf("after emptying, At(0)=%q", got)
+	}
+}
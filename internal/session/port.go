@@ -9,8 +9,9 @@ import "time"
 type Recorder interface {
 	// Initialize is called exactly once when the Session arms for recording. The adapter
 	// is responsible for writing any header it needs and for persisting the supplied
-	// backlog (every execution already in History at the moment recording begins).
-	Initialize(command string, interval time.Duration, backlog []Execution) error
+	// backlog (every execution already in History at the moment recording begins). argv
+	// is nil unless the command runs without a shell (Session.Argv).
+	Initialize(command string, argv []string, interval time.Duration, backlog History) error
 
 	// WriteFrame persists one novel execution. Called for every execution
 	// RecordIfChanged accepted into History after Initialize has run.
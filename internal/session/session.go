@@ -2,7 +2,6 @@ package session
 
 import (
 	"errors"
-	"slices"
 	"time"
 )
 
@@ -13,6 +12,24 @@ type Execution struct {
 	Stderr    string
 	ExitCode  int
 	Error     error
+	TimedOut  bool // killed at the runner's timeout; Error says after how long
+	// Duration is the run's wall-clock time and Usage its resources; both zero when
+	// unknown (a recording made before they were captured, or a program that never started).
+	Duration time.Duration
+	Usage    Usage
+}
+
+// Usage is the resource usage of a run, from the OS's accounting of the process and the
+// children it waited for.
+type Usage struct {
+	User, Sys time.Duration // CPU time
+	MaxRSS    int64         // peak resident set size in bytes; 0 = unknown
+}
+
+// Failed reports whether the run did not exit zero, including one that could not be
+// started or was killed.
+func (e *Execution) Failed() bool {
+	return e.ExitCode != 0 || e.Error != nil
 }
 
 // Output returns combined stdout and stderr
@@ -32,11 +49,21 @@ func (e *Execution) Output() string {
 // session itself) but knows nothing about JSONL, file I/O, or path normalization —
 // recording.Flow is the entry point external callers should use.
 type Session struct {
-	Command    string
+	Command string
+	// Argv is the program and arguments when the command runs without a shell (-x), in
+	// which case Command is their quoted display form; nil for a shell command.
+	Argv       []string
 	Interval   time.Duration
-	History    []Execution
+	History    History
 	MaxHistory int
-	recorder   Recorder // nil ⇔ not recording
+	// MaxHistoryBytes, when positive, also caps the history by History.Bytes: the oldest
+	// executions are evicted until it fits, keeping at least the newest.
+	MaxHistoryBytes int64
+	// Mask, when set, normalises outputs before RecordIfChanged compares them, so a
+	// frame differing only in masked text (a clock, a request ID) is not novel. History
+	// keeps the raw output.
+	Mask     func(output string) string
+	recorder Recorder // nil ⇔ not recording
 }
 
 // NewSession creates a new session
@@ -44,6 +71,7 @@ func NewSession(command string, interval time.Duration) *Session {
 	return &Session{
 		Command:  command,
 		Interval: interval,
+		History:  NewLineHistory(),
 	}
 }
 
@@ -53,24 +81,23 @@ func NewSession(command string, interval time.Duration) *Session {
 // annotation, OSC9 bell, replay) loses the transition. When a recording is active and the
 // frame is added, the frame is also written to the file; a write error auto-finalizes the
 // recording (close + clear) and is returned. added reports whether the execution was novel;
-// evicted reports whether MaxHistory just rotated the oldest entry out (callers tracking a
-// history cursor need to decrement it).
-func (s *Session) RecordIfChanged(exec Execution) (added bool, evicted bool, err error) {
-	if len(s.History) > 0 {
-		last := s.History[len(s.History)-1]
-		if exec.Output() == last.Output() &&
+// evicted is how many of the oldest entries MaxHistory and MaxHistoryBytes just rotated out
+// (callers tracking a history cursor need to shift it down by as many). Outputs are compared
+// through Mask when it is set.
+func (s *Session) RecordIfChanged(exec Execution) (added bool, evicted int, err error) {
+	if n := s.History.Len(); n > 0 {
+		last := s.History.At(n - 1)
+		if s.masked(exec.Output()) == s.masked(last.Output()) &&
 			exec.ExitCode == last.ExitCode &&
 			errString(exec.Error) == errString(last.Error) {
-			return false, false, nil
+			return false, 0, nil
 		}
 	}
-	s.History = append(s.History, exec)
-	if s.MaxHistory > 0 && len(s.History) > s.MaxHistory {
-		// slices.Delete (rather than History[1:]) copy-shifts and zeros the freed tail slot,
-		// so the evicted Execution's stdout/stderr strings are released for GC instead of
-		// staying pinned in the backing array until the next slice growth.
-		s.History = slices.Delete(s.History, 0, 1)
-		evicted = true
+	s.History.Append(exec)
+	for s.History.Len() > 1 && (s.MaxHistory > 0 && s.History.Len() > s.MaxHistory ||
+		s.MaxHistoryBytes > 0 && s.History.Bytes() > s.MaxHistoryBytes) {
+		s.History.DropOldest()
+		evicted++
 	}
 	if s.recorder != nil {
 		if writeErr := s.recorder.WriteFrame(exec); writeErr != nil {
@@ -82,6 +109,13 @@ func (s *Session) RecordIfChanged(exec Execution) (added bool, evicted bool, err
 	return true, evicted, nil
 }
 
+func (s *Session) masked(output string) string {
+	if s.Mask == nil {
+		return output
+	}
+	return s.Mask(output)
+}
+
 func errString(e error) string {
 	if e == nil {
 		return ""
@@ -90,7 +124,7 @@ func errString(e error) string {
 }
 
 // StartRecording arms the session to persist subsequent additions through rec.
-// rec.Initialize is called with the current Command, Interval, and the History backlog.
+// rec.Initialize is called with the current Command, Argv, Interval, and the History backlog.
 // External callers should drive recording through recording.Flow rather than constructing
 // the Recorder + calling StartRecording directly; this signature exists for Flow's use
 // and for in-package tests.
@@ -98,7 +132,7 @@ func (s *Session) StartRecording(rec Recorder) error {
 	if s.recorder != nil {
 		return errors.New("session: already recording")
 	}
-	if err := rec.Initialize(s.Command, s.Interval, s.History); err != nil {
+	if err := rec.Initialize(s.Command, s.Argv, s.Interval, s.History); err != nil {
 		return err
 	}
 	s.recorder = rec
@@ -115,6 +149,11 @@ func (s *Session) StopRecording() error {
 	return err
 }
 
+// Close stops any recording and releases the history's resources. Idempotent.
+func (s *Session) Close() error {
+	return errors.Join(s.StopRecording(), s.History.Close())
+}
+
 // IsRecording reports whether a recording is currently active. External callers
 // (cmd/wch, internal/tui) should query recording.Flow.IsActive() instead — this method
 // exists so the recording package's Flow can read through to the session's single
@@ -1,6 +1,7 @@
 package session_test
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -18,8 +19,8 @@ func TestRecordIfChangedFirstExecution(t *testing.T) {
 	if !added {
 		t.Errorf("first execution should be added")
 	}
-	if len(s.History) != 1 {
-		t.Errorf("History len=%d want 1", len(s.History))
+	if s.History.Len() != 1 {
+		t.Errorf("History len=%d want 1", s.History.Len())
 	}
 }
 
@@ -34,8 +35,8 @@ func TestRecordIfChangedDeduplicates(t *testing.T) {
 	if added {
 		t.Errorf("duplicate output should not be added")
 	}
-	if len(s.History) != 1 {
-		t.Errorf("History len=%d want 1", len(s.History))
+	if s.History.Len() != 1 {
+		t.Errorf("History len=%d want 1", s.History.Len())
 	}
 }
 
@@ -50,8 +51,26 @@ func TestRecordIfChangedAddsChanged(t *testing.T) {
 	if !added {
 		t.Errorf("changed output should be added")
 	}
-	if len(s.History) != 2 {
-		t.Errorf("History len=%d want 2", len(s.History))
+	if s.History.Len() != 2 {
+		t.Errorf("History len=%d want 2", s.History.Len())
+	}
+}
+
+// With a Mask, a frame that differs only in masked text is a duplicate; History keeps the
+// raw output of the first.
+func TestRecordIfChangedComparesMasked(t *testing.T) {
+	s := session.NewSession("x", time.Second)
+	s.Mask = func(out string) string { return strings.Map(func(r rune) rune { return max(r, '9') }, out) }
+	_, _, _ = s.RecordIfChanged(session.Execution{Stdout: "updated 12:03:44"})
+	added, _, _ := s.RecordIfChanged(session.Execution{Stdout: "updated 12:03:45"})
+	if added {
+		t.Errorf("frame differing only in masked digits should not be added")
+	}
+	if s.History.At(0).Stdout != "updated 12:03:44" {
+		t.Errorf("History kept %q, want the raw output", s.History.At(0).Stdout)
+	}
+	if added, _, _ := s.RecordIfChanged(session.Execution{Stdout: "stale 12:03:45"}); !added {
+		t.Errorf("frame differing outside the mask should be added")
 	}
 }
 
@@ -62,12 +81,34 @@ func TestRecordIfChangedTrimsAtMaxHistory(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		_, _, _ = s.RecordIfChanged(session.Execution{Stdout: string(rune('a' + i))})
 	}
-	if got, want := len(s.History), 3; got != want {
+	if got, want := s.History.Len(), 3; got != want {
 		t.Fatalf("History len=%d want %d", got, want)
 	}
 	// We should have kept the *last* three.
-	if s.History[0].Stdout != "c" || s.History[2].Stdout != "e" {
-		t.Errorf("trimmed wrong end; got %q..%q", s.History[0].Stdout, s.History[2].Stdout)
+	if s.History.At(0).Stdout != "c" || s.History.At(2).Stdout != "e" {
+		t.Errorf("trimmed wrong end; got %q..%q", s.History.At(0).Stdout, s.History.At(2).Stdout)
+	}
+}
+
+// MaxHistoryBytes evicts as many of the oldest frames as it takes to fit, never the newest,
+// and reports how many went.
+func TestRecordIfChangedTrimsAtMaxHistoryBytes(t *testing.T) {
+	s := session.NewSession("x", time.Second)
+	s.History = session.NewMemoryHistory() // Bytes counts output exactly
+	s.MaxHistoryBytes = 12
+	for _, out := range []string{"aaaa", "bbbb", "cccc"} {
+		if _, evicted, _ := s.RecordIfChanged(session.Execution{Stdout: out}); evicted != 0 {
+			t.Fatalf("%q evicted %d, want 0", out, evicted)
+		}
+	}
+	if _, evicted, _ := s.RecordIfChanged(session.Execution{Stdout: "dddddddd"}); evicted != 2 {
+		t.Errorf("evicted %d, want 2", evicted)
+	}
+	if _, evicted, _ := s.RecordIfChanged(session.Execution{Stdout: strings.Repeat("e", 20)}); evicted != 2 {
+		t.Errorf("oversized frame: evicted %d, want 2 (the newest stays)", evicted)
+	}
+	if s.History.Len() != 1 || s.History.Bytes() != 20 {
+		t.Errorf("History len=%d bytes=%d, want the oversized frame alone", s.History.Len(), s.History.Bytes())
 	}
 }
 
@@ -134,7 +175,38 @@ func TestRecordingAutoFinalizeOnWriteError(t *testing.T) {
 		t.Errorf("IsRecording() should be false after auto-finalize")
 	}
 	// And the frame *is* in History — persistence failed but in-memory dedupe succeeded.
-	if len(s.History) != 1 {
-		t.Errorf("History len=%d want 1", len(s.History))
+	if s.History.Len() != 1 {
+		t.Errorf("History len=%d want 1", s.History.Len())
+	}
+}
+
+// Meta gives back an execution's metadata without its outputs, whichever backend holds it
+// and wherever the outputs are.
+func TestHistoryMeta(t *testing.T) {
+	for name, h := range map[string]session.History{
+		"memory": session.NewMemoryHistory(),
+		"lines":  session.NewLineHistory(),
+		"spool":  session.NewSpoolHistory(t.TempDir(), 0), // every output but the newest spilled
+	} {
+		for i := range 3 {
+			h.Append(session.Execution{
+				Timestamp: time.Date(2026, 5, 30, 12, 0, i, 0, time.UTC),
+				Stdout:    "out\n",
+				Stderr:    "err\n",
+				ExitCode:  i,
+				TimedOut:  i == 1,
+				Duration:  time.Duration(i+1) * time.Second,
+			})
+		}
+		for i := range 3 {
+			e := h.Meta(i)
+			if e.Stdout != "" || e.Stderr != "" || e.ExitCode != i || e.TimedOut != (i == 1) || e.Duration != time.Duration(i+1)*time.Second {
+				t.Errorf("%s: Meta(%d) = %+v", name, i, e)
+			}
+		}
+		if got := h.At(0).Stdout; got != "out\n" {
+			t.Errorf("%s: At(0).Stdout=%q after Meta", name, got)
+		}
+		_ = h.Close()
 	}
 }
@@ -0,0 +1,189 @@
+package session
+
+import (
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+// spoolCompactMin is how much evicted output must sit at the start of a spool file before it
+// is worth rewriting the file without it.
+const spoolCompactMin = 1 << 20
+
+// SpoolHistory is a History that keeps the newest executions' outputs in memory, up to a
+// byte budget, and spills older ones to a temporary file, holding only their offsets and
+// metadata. The file is created on the first spill and unlinked as soon as it is, so a crash
+// leaves nothing behind. An output that cannot be spilled stays in memory.
+type SpoolHistory struct {
+	dir      string // for the temporary file; "" = os.TempDir
+	resident int64  // budget for outputs held in memory
+
+	entries  []spoolEntry
+	inMemory int   // entries[inMemory:] hold their outputs; the ones before are spilled
+	memBytes int64 // output bytes of entries[inMemory:]
+	bytes    int64 // output bytes of every entry
+
+	f      *os.File // nil until the first spill
+	name   string   // the file's path while it still has one to remove on Close
+	end    int64    // where the next spill is written
+	failed bool     // spilling failed; everything stays in memory from then on
+}
+
+// spoolEntry is one execution of a SpoolHistory. Once spilled, exec keeps everything but
+// Stdout and Stderr, which are at off in the file, stdout first.
+type spoolEntry struct {
+	exec       Execution
+	off        int64
+	nout, nerr int
+}
+
+func (e spoolEntry) size() int64 { return int64(e.nout + e.nerr) }
+
+// NewSpoolHistory returns an empty SpoolHistory keeping up to resident bytes of output in
+// memory (the newest execution always stays), spilling to a file in dir ("" = the default
+// temporary directory).
+func NewSpoolHistory(dir string, resident int64) *SpoolHistory {
+	return &SpoolHistory{dir: dir, resident: resident}
+}
+
+func (h *SpoolHistory) Len() int                  { return len(h.entries) }
+func (h *SpoolHistory) Timestamp(i int) time.Time { return h.entries[i].exec.Timestamp }
+func (h *SpoolHistory) Meta(i int) Execution      { return withoutOutput(h.entries[i].exec) }
+
+// Bytes is the output the history holds, in memory and spilled.
+func (h *SpoolHistory) Bytes() int64 { return h.bytes }
+
+// Append adds e, then spills the oldest outputs held in memory until they fit the budget.
+func (h *SpoolHistory) Append(e Execution) {
+	entry := spoolEntry{exec: e, nout: len(e.Stdout), nerr: len(e.Stderr)}
+	h.entries = append(h.entries, entry)
+	h.memBytes += entry.size()
+	h.bytes += entry.size()
+	for h.memBytes > h.resident && h.inMemory < len(h.entries)-1 && !h.failed {
+		if err := h.spill(&h.entries[h.inMemory]); err != nil {
+			h.failed = true
+			break
+		}
+		h.memBytes -= h.entries[h.inMemory].size()
+		h.inMemory++
+	}
+}
+
+// spill writes e's outputs to the end of the file and drops them from memory.
+func (h *SpoolHistory) spill(e *spoolEntry) error {
+	if h.f == nil {
+		f, err := os.CreateTemp(h.dir, "wch-history-*")
+		if err != nil {
+			return err
+		}
+		h.f = f
+		if os.Remove(f.Name()) != nil {
+			h.name = f.Name() // not unlinkable while open here; removed on Close
+		}
+	}
+	buf := make([]byte, 0, e.size())
+	buf = append(append(buf, e.exec.Stdout...), e.exec.Stderr...)
+	if _, err := h.f.WriteAt(buf, h.end); err != nil {
+		return err
+	}
+	e.off = h.end
+	h.end += e.size()
+	e.exec.Stdout, e.exec.Stderr = "", ""
+	return nil
+}
+
+// At returns execution i, reading its outputs back from the file if they were spilled. One
+// that cannot be read back comes with an Error saying so.
+func (h *SpoolHistory) At(i int) Execution {
+	e := h.entries[i]
+	if i >= h.inMemory {
+		return e.exec
+	}
+	exec := e.exec
+	buf := make([]byte, e.size())
+	if _, err := h.f.ReadAt(buf, e.off); err != nil {
+		exec.Error = errors.Join(exec.Error, errors.New("history: spilled output unreadable: "+err.Error()))
+		return exec
+	}
+	exec.Stdout, exec.Stderr = string(buf[:e.nout]), string(buf[e.nout:])
+	return exec
+}
+
+// DropOldest evicts the oldest execution. Spilled outputs are evicted oldest first, so what
+// they leave behind is always the start of the file; once that is most of it (and at least
+// spoolCompactMin), the rest is copied to a fresh file.
+func (h *SpoolHistory) DropOldest() {
+	e := h.entries[0]
+	h.bytes -= e.size()
+	if h.inMemory > 0 {
+		h.inMemory--
+	} else {
+		h.memBytes -= e.size()
+	}
+	h.entries = slices.Delete(h.entries, 0, 1)
+	if h.inMemory == 0 {
+		if h.f != nil && h.end > 0 {
+			// Nothing spilled is left: start the file over.
+			h.end = 0
+			_ = h.f.Truncate(0)
+		}
+		return
+	}
+	if dead := h.entries[0].off; dead >= spoolCompactMin && dead > h.end/2 {
+		if err := h.compact(); err != nil {
+			h.failed = true
+		}
+	}
+}
+
+// compact copies the spilled outputs still held to a new file, dropping the evicted ones
+// before them.
+func (h *SpoolHistory) compact() error {
+	old, oldName := h.f, h.name
+	h.f, h.name = nil, ""
+	f, err := os.CreateTemp(h.dir, "wch-history-*")
+	if err != nil {
+		h.f, h.name = old, oldName
+		return err
+	}
+	if os.Remove(f.Name()) != nil {
+		h.name = f.Name()
+	}
+	base := h.entries[0].off
+	if _, err := io.Copy(f, io.NewSectionReader(old, base, h.end-base)); err != nil {
+		_ = f.Close()
+		if h.name != "" {
+			_ = os.Remove(h.name)
+		}
+		h.f, h.name = old, oldName
+		return err
+	}
+	for i := range h.inMemory {
+		h.entries[i].off -= base
+	}
+	h.end -= base
+	h.f = f
+	_ = old.Close()
+	if oldName != "" {
+		_ = os.Remove(oldName)
+	}
+	return nil
+}
+
+// Close removes the spool file. Idempotent.
+func (h *SpoolHistory) Close() error {
+	if h.f == nil {
+		return nil
+	}
+	err := h.f.Close()
+	if h.name != "" {
+		err = errors.Join(err, os.Remove(h.name))
+	}
+	h.f, h.name = nil, ""
+	return err
+}
+
+// Compile-time guarantee that SpoolHistory satisfies History.
+var _ History = (*SpoolHistory)(nil)
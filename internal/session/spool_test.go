@@ -0,0 +1,70 @@
+package session_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// spoolFrame is execution i of a spool test: a distinct stdout of size bytes and a stderr.
+func spoolFrame(i, size int) session.Execution {
+	return session.Execution{
+		Timestamp: time.Date(2026, 5, 30, 12, 0, 0, 0, time.UTC).Add(time.Duration(i) * time.Second),
+		Stdout:    fmt.Sprintf("%06d", i) + strings.Repeat("x", size-6),
+		Stderr:    fmt.Sprintf("err %d", i),
+		ExitCode:  i % 3,
+	}
+}
+
+// A SpoolHistory reads back every execution whole, whether it stayed in memory or was
+// spilled, while evictions (and the file rewrites they trigger) go on; its file never has a
+// name in the directory.
+func TestSpoolHistory(t *testing.T) {
+	dir := t.TempDir()
+	const size = 256 << 10
+	s := session.NewSession("x", time.Second)
+	s.History = session.NewSpoolHistory(dir, 2*size)
+	s.MaxHistoryBytes = 16 * size
+	first := 0
+	for i := range 60 {
+		_, evicted, _ := s.RecordIfChanged(spoolFrame(i, size))
+		first += evicted
+		for j := range s.History.Len() {
+			want := spoolFrame(first+j, size)
+			got := s.History.At(j)
+			if got.Stdout != want.Stdout || got.Stderr != want.Stderr || got.ExitCode != want.ExitCode || got.Error != nil {
+				t.Fatalf("after %d: At(%d) = frame %.6s (%v), want %d", i, j, got.Stdout, got.Error, first+j)
+			}
+			if !s.History.Timestamp(j).Equal(want.Timestamp) {
+				t.Fatalf("after %d: Timestamp(%d) = %v", i, j, s.History.Timestamp(j))
+			}
+		}
+	}
+	if first == 0 || s.History.Bytes() > s.MaxHistoryBytes {
+		t.Errorf("evicted %d, holding %d bytes", first, s.History.Bytes())
+	}
+	if names, _ := os.ReadDir(dir); len(names) != 0 {
+		t.Errorf("spool left %d files in its directory", len(names))
+	}
+	if err := s.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+// The newest execution always stays in memory, however small the budget.
+func TestSpoolHistoryKeepsNewest(t *testing.T) {
+	h := session.NewSpoolHistory(t.TempDir(), 0)
+	defer func() { _ = h.Close() }()
+	h.Append(spoolFrame(0, 100))
+	h.Append(spoolFrame(1, 100))
+	if got := h.At(1); got.Stdout != spoolFrame(1, 100).Stdout {
+		t.Errorf("newest = %.6s", got.Stdout)
+	}
+	if got := h.At(0); got.Stdout != spoolFrame(0, 100).Stdout {
+		t.Errorf("spilled = %.6s (%v)", got.Stdout, got.Error)
+	}
+}
@@ -0,0 +1,162 @@
+package trigger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// fileEvents are the inotify events that count as a change. IN_MODIFY fires on every write
+// of a file being written; the debounce folds those into one run.
+const fileEvents = unix.IN_MODIFY | unix.IN_CLOSE_WRITE | unix.IN_ATTRIB | unix.IN_CREATE |
+	unix.IN_DELETE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO
+
+// Files is a Source that reports changes to files and directory trees, through inotify.
+type Files struct {
+	f      *os.File // the inotify descriptor, non-blocking so Close interrupts the reader
+	events chan string
+
+	mu    sync.Mutex
+	watch map[int]*watchedDir // by watch descriptor
+}
+
+// watchedDir is one inotify watch: a directory, and which of its entries count.
+type watchedDir struct {
+	path      string
+	all       bool            // every entry (a watched tree), else only names
+	recursive bool            // watch subdirectories created later too
+	names     map[string]bool // watched files within path
+}
+
+// WatchFiles watches paths for changes: a file is watched through its directory, so an
+// editor replacing it by rename still counts; a directory is watched with everything below
+// it, including directories created later. Hidden entries below a watched directory (.git,
+// an editor's .swp files) do not count.
+func WatchFiles(paths []string) (*Files, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify: %w", err)
+	}
+	w := &Files{f: os.NewFile(uintptr(fd), "inotify"), events: make(chan string, 1), watch: map[int]*watchedDir{}}
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err == nil {
+			if info.IsDir() {
+				err = w.addTree(p)
+			} else {
+				err = w.add(filepath.Dir(p), filepath.Base(p), false)
+			}
+		}
+		if err != nil {
+			w.f.Close()
+			return nil, err
+		}
+	}
+	raw := make(chan string)
+	go w.read(raw)
+	go debounce(raw, w.events, Debounce)
+	return w, nil
+}
+
+// Events implements Source.
+func (w *Files) Events() <-chan string { return w.events }
+
+// Close implements Source. Closing the inotify descriptor ends the reader, which closes
+// Events.
+func (w *Files) Close() error {
+	if err := w.f.Close(); !errors.Is(err, os.ErrClosed) {
+		return err
+	}
+	return nil
+}
+
+// add watches dir for changes to name, or to every entry when name is "" (recursive then
+// says whether new subdirectories are watched as they appear).
+func (w *Files) add(dir, name string, recursive bool) error {
+	var wd int
+	raw, err := w.f.SyscallConn()
+	if err == nil {
+		cerr := raw.Control(func(fd uintptr) { wd, err = unix.InotifyAddWatch(int(fd), dir, fileEvents) })
+		err = errors.Join(err, cerr)
+	}
+	if err != nil {
+		return fmt.Errorf("watch %s: %w", dir, err)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	d := w.watch[wd]
+	if d == nil {
+		d = &watchedDir{path: dir, names: map[string]bool{}}
+		w.watch[wd] = d
+	}
+	if name == "" {
+		d.all, d.recursive = true, d.recursive || recursive
+	} else {
+		d.names[name] = true
+	}
+	return nil
+}
+
+// addTree watches root and its subdirectories, skipping hidden ones below root.
+func (w *Files) addTree(root string) error {
+	return filepath.WalkDir(root, func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !e.IsDir() {
+			return nil
+		}
+		if p != root && strings.HasPrefix(e.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return w.add(p, "", true)
+	})
+}
+
+// read turns inotify events into the paths they concern until the descriptor is closed.
+func (w *Files) read(raw chan<- string) {
+	defer close(raw)
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := w.f.Read(buf)
+		if err != nil {
+			return
+		}
+		for off := 0; off+unix.SizeofInotifyEvent <= n; {
+			ev := (*unix.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			nameBytes := buf[off+unix.SizeofInotifyEvent : off+unix.SizeofInotifyEvent+int(ev.Len)]
+			off += unix.SizeofInotifyEvent + int(ev.Len)
+			name := string(bytes.TrimRight(nameBytes, "\x00"))
+			if p, ok := w.changed(int(ev.Wd), ev.Mask, name); ok {
+				raw <- p
+			}
+		}
+	}
+}
+
+// changed maps one event to the path it reports, if it concerns a watched entry, and
+// extends a recursive watch to a directory that just appeared.
+func (w *Files) changed(wd int, mask uint32, name string) (string, bool) {
+	w.mu.Lock()
+	d := w.watch[wd]
+	if mask&unix.IN_IGNORED != 0 {
+		delete(w.watch, wd)
+	}
+	w.mu.Unlock()
+	if d == nil || name == "" || !(d.names[name] || d.all && !strings.HasPrefix(name, ".")) {
+		return "", false
+	}
+	p := filepath.Join(d.path, name)
+	if d.recursive && mask&unix.IN_ISDIR != 0 && mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 {
+		_ = w.addTree(p) // best effort: it may already be gone again
+	}
+	return p, true
+}
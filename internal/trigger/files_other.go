@@ -0,0 +1,20 @@
+//go:build !linux
+
+package trigger
+
+import "errors"
+
+// Files is a Source that reports changes to files and directory trees. It is only
+// implemented on Linux (inotify).
+type Files struct{}
+
+// WatchFiles fails outside Linux.
+func WatchFiles([]string) (*Files, error) {
+	return nil, errors.New("watching files is not supported on this platform")
+}
+
+// Events implements Source.
+func (*Files) Events() <-chan string { return nil }
+
+// Close implements Source.
+func (*Files) Close() error { return nil }
@@ -0,0 +1,48 @@
+package trigger
+
+import (
+	"sync"
+	"time"
+)
+
+// Signal is a Source fed from within wch: every Fire is an event, and a burst of them is
+// debounced into one, named by cause. It delivers a streaming command's new output.
+type Signal struct {
+	cause  string
+	events chan string
+
+	mu     sync.Mutex
+	raw    chan string
+	closed bool
+}
+
+// NewSignal returns a Signal whose events are cause, debounced by quiet.
+func NewSignal(cause string, quiet time.Duration) *Signal {
+	s := &Signal{cause: cause, events: make(chan string, 1), raw: make(chan string)}
+	go debounce(s.raw, s.events, quiet)
+	return s
+}
+
+// Fire reports an event; after Close it does nothing. Safe for concurrent use. The debounce
+// always drains raw, so the send does not hold the lock for long.
+func (s *Signal) Fire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.raw <- s.cause
+	}
+}
+
+// Events implements Source.
+func (s *Signal) Events() <-chan string { return s.events }
+
+// Close implements Source.
+func (s *Signal) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.raw)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Package trigger supplies reasons to run the watched command other than the schedule's
+// ticks. A Source is consulted by whichever driver runs the watch (the TUI or the headless
+// loop) alongside its tick: each value it delivers starts a run, subject to the same overlap
+// Gate as a tick, and names what caused it for the status bar.
+package trigger
+
+import "time"
+
+// Source delivers the causes of runs, one per burst of activity.
+type Source interface {
+	// Events returns the channel of causes (a changed path for Files). It is closed when
+	// the source stops.
+	Events() <-chan string
+	// Close stops the source and releases what it holds. Idempotent.
+	Close() error
+}
+
+// Debounce is how long a burst of file events must go quiet before it triggers a run: an
+// editor's save or a checkout is several writes, renames and chmods in quick succession.
+const Debounce = 100 * time.Millisecond
+
+// maxBurst bounds a burst to this many quiet periods from its first value, so a path written
+// more often than the quiet period (a log being appended to) still triggers runs.
+const maxBurst = 10
+
+// debounce forwards the first value of every burst on raw to out once raw has been quiet
+// for quiet, or maxBurst × quiet after the burst began if it never goes quiet, and closes out
+// when raw closes. A burst that arrives while out still holds an undelivered value is merged
+// into it: the driver only needs to know that something changed since it last looked.
+func debounce(raw <-chan string, out chan<- string, quiet time.Duration) {
+	defer close(out)
+	for first := range raw {
+		timer := time.NewTimer(quiet)
+		deadline := time.NewTimer(maxBurst * quiet)
+	burst:
+		for {
+			select {
+			case _, ok := <-raw:
+				if !ok {
+					timer.Stop()
+					deadline.Stop()
+					return
+				}
+				timer.Reset(quiet)
+			case <-timer.C:
+				break burst
+			case <-deadline.C:
+				break burst
+			}
+		}
+		timer.Stop()
+		deadline.Stop()
+		select {
+		case out <- first:
+		default:
+		}
+	}
+}
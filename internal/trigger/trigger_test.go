@@ -0,0 +1,123 @@
+package trigger
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// A burst is one event, carrying its first value, delivered once the burst goes quiet.
+func TestDebounceCoalescesBurst(t *testing.T) {
+	raw, out := make(chan string), make(chan string, 1)
+	go debounce(raw, out, 20*time.Millisecond)
+	raw <- "a"
+	raw <- "b"
+	raw <- "c"
+	select {
+	case got := <-out:
+		if got != "a" {
+			t.Errorf("got %q want a", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event after the burst")
+	}
+	close(raw)
+	if _, ok := <-out; ok {
+		t.Error("out should close with raw")
+	}
+}
+
+// A burst that never goes quiet is flushed after maxBurst quiet periods instead of holding
+// its event back for as long as the writes go on.
+func TestDebounceFlushesEndlessBurst(t *testing.T) {
+	const quiet = 20 * time.Millisecond
+	raw, out := make(chan string), make(chan string, 1)
+	go debounce(raw, out, quiet)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		defer close(raw)
+		for {
+			select {
+			case raw <- "app.log":
+			case <-stop:
+				return
+			}
+			time.Sleep(quiet / 4)
+		}
+	}()
+	start := time.Now()
+	select {
+	case got := <-out:
+		if got != "app.log" {
+			t.Errorf("got %q want app.log", got)
+		}
+		if waited := time.Since(start); waited < maxBurst*quiet/2 {
+			t.Errorf("flushed after %v, before the burst could have gone quiet", waited)
+		}
+	case <-time.After(50 * maxBurst * quiet):
+		t.Fatal("no event while the writes go on")
+	}
+}
+
+func TestWatchFiles(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("inotify is Linux-only")
+	}
+	dir := t.TempDir()
+	file := filepath.Join(dir, "watched.txt")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tree := filepath.Join(dir, "tree")
+	if err := os.Mkdir(tree, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	w, err := WatchFiles([]string{file, tree})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case got := <-w.Events():
+			if got != want {
+				t.Errorf("event %q want %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no event for %s", want)
+		}
+	}
+	write := func(p string) {
+		t.Helper()
+		if err := os.WriteFile(p, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A sibling of a watched file does not count; the file itself does.
+	write(filepath.Join(dir, "other.txt"))
+	write(file)
+	expect(file)
+
+	// A directory created inside a watched tree is watched too.
+	sub := filepath.Join(tree, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	expect(sub)
+	write(filepath.Join(sub, "new.go"))
+	expect(filepath.Join(sub, "new.go"))
+
+	// Hidden entries below a tree do not count.
+	write(filepath.Join(tree, ".swp"))
+	select {
+	case got := <-w.Events():
+		t.Errorf("unexpected event %q", got)
+	case <-time.After(3 * Debounce):
+	}
+}
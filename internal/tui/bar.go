@@ -1,10 +1,15 @@
 package tui
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	"charm.land/bubbles/v2/key"
 	"charm.land/lipgloss/v2"
+
+	"github.com/ivoronin/wch/internal/schedule"
+	"github.com/ivoronin/wch/internal/session"
 )
 
 // barShown reports whether the bottom bar takes up a screen row right now —
@@ -30,6 +35,114 @@ func (m Model) renderBarLayout(leftContent, indicator, helpText string) string {
 	return statusBarStyle.Width(m.width).Render(content)
 }
 
+// barTitle is the bar's left slot outside search: the command, led by the cost of the run
+// at the cursor, the pinned baseline's timestamp, what started the latest run when a
+// trigger is set, the next planned run (or under -backoff the effective interval; nothing
+// for a plain interval), the count of ticks the overlap policy skipped, and a timeout mark
+// when the run at the cursor was killed at -timeout ("⧖ timed out · 3 skipped · next
+// 12:05:00 · via src/main.go · base 12:00:01 · 1.23s cpu 0.41s 38MiB · make test"), so they
+// survive truncation. With the memory stat on ('M'), the history's size and its share of
+// -history-bytes lead all of these ("hist 1200 · 38MiB/256MiB"). A pane of Multi leads with
+// its label.
+func (m Model) barTitle() string {
+	title := m.session.Command
+	var cur session.Execution // the run at the cursor, without its output
+	if i, ok := m.cursor.At(); ok && i < m.session.History.Len() {
+		cur = m.session.History.Meta(i)
+	}
+	if cost := runCost(cur); cost != "" {
+		title = cost + " · " + title
+	}
+	if b, ok := m.frames.Pinned(); ok {
+		title = "base " + b.Timestamp.Format(timestampFmt) + " · " + title
+	}
+	if m.trigger != nil && m.cause != "" {
+		title = "via " + m.cause + " · " + title
+	}
+	switch sc := m.sched.(type) {
+	case schedule.Every:
+	case *schedule.Backoff:
+		title = "every " + sc.Interval().String() + " · " + title
+	default:
+		if !m.nextRun.IsZero() {
+			title = "next " + m.nextRun.Format(timestampFmt) + " · " + title
+		}
+	}
+	if n := m.gate.Skipped(); n > 0 {
+		title = fmt.Sprintf("%d skipped · %s", n, title)
+	}
+	if cur.TimedOut {
+		title = timeoutMark + " · " + title
+	}
+	if m.prefs.Memory {
+		title = historyStat(m.session) + " · " + title
+	}
+	if m.label != "" {
+		title = m.label + " " + title
+	}
+	return title
+}
+
+// timeoutMark leads the bar title while the frame at the cursor is a timed-out run. It keeps
+// the bar's own colours (a styled span would reset the plate mid-bar), so the glyph and words
+// set it apart from an ordinary failure, which only the body's exit annotation shows.
+const timeoutMark = "⧖ timed out"
+
+// runCost summarises how long a run took and what it used ("1.23s cpu 0.41s 38MiB"), or ""
+// when nothing was captured (a recording made before durations were). Parts that are
+// unknown are left out.
+func runCost(e session.Execution) string {
+	if e.Duration == 0 {
+		return ""
+	}
+	parts := []string{shortDuration(e.Duration).String()}
+	if cpu := e.Usage.User + e.Usage.Sys; cpu > 0 {
+		parts = append(parts, "cpu "+shortDuration(cpu).String())
+	}
+	if e.Usage.MaxRSS > 0 {
+		parts = append(parts, binaryBytes(e.Usage.MaxRSS))
+	}
+	return strings.Join(parts, " ")
+}
+
+// shortDuration rounds d to about three significant digits: "340ms", "1.23s", "2m5s".
+func shortDuration(d time.Duration) time.Duration {
+	switch {
+	case d < time.Second:
+		return d.Round(time.Millisecond)
+	case d < time.Minute:
+		return d.Round(10 * time.Millisecond)
+	default:
+		return d.Round(time.Second)
+	}
+}
+
+// historyStat is the memory stat: how many executions the history holds and what they take
+// up, against MaxHistoryBytes when it is set.
+func historyStat(s *session.Session) string {
+	stat := fmt.Sprintf("hist %d · %s", s.History.Len(), binaryBytes(s.History.Bytes()))
+	if s.MaxHistoryBytes > 0 {
+		stat += "/" + binaryBytes(s.MaxHistoryBytes)
+	}
+	return stat
+}
+
+// binaryBytes renders n with a binary unit: "512B", "7.5KiB", "38MiB".
+func binaryBytes(n int64) string {
+	const units = "KMGTPE"
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+	v, u := float64(n)/1024, 0
+	for v >= 1024 && u < len(units)-1 {
+		v, u = v/1024, u+1
+	}
+	if v < 10 {
+		return fmt.Sprintf("%.1f%ciB", v, units[u])
+	}
+	return fmt.Sprintf("%.0f%ciB", v, units[u])
+}
+
 // centerBlockWidth is the fixed cell width of the centered clock-group:
 //
 //	[2 left-pad][rec slot 1][1 gap][timestamp][1 gap][indicator 1][2 right-pad]
@@ -80,7 +193,7 @@ func (m Model) renderIndicator() string {
 		indicator = "⎌"
 	case m.prefs.Paused:
 		indicator = "⏸"
-	case m.executing:
+	case m.gate.Running() > 0:
 		indicator = "*"
 	default:
 		indicator = "·"
@@ -173,3 +173,91 @@ func TestRenderIndicatorLiveNotReplay(t *testing.T) {
 		t.Errorf("live indicator must not contain ▶; got %q", got)
 	}
 }
+
+// runCost shows what was captured and leaves out what was not.
+func TestRunCost(t *testing.T) {
+	tests := []struct {
+		exec session.Execution
+		want string
+	}{
+		{session.Execution{}, ""},
+		{session.Execution{Duration: 1234567 * time.Microsecond}, "1.23s"},
+		{session.Execution{
+			Duration: 340 * time.Millisecond,
+			Usage:    session.Usage{User: 300 * time.Millisecond, Sys: 110 * time.Millisecond, MaxRSS: 38 << 20},
+		}, "340ms cpu 410ms 38MiB"},
+		{session.Execution{Duration: 125 * time.Second, Usage: session.Usage{MaxRSS: 7680}}, "2m5s 7.5KiB"},
+	}
+	for _, tc := range tests {
+		if got := runCost(tc.exec); got != tc.want {
+			t.Errorf("runCost(%+v)=%q want %q", tc.exec, got, tc.want)
+		}
+	}
+}
+
+// The picker shows the selected run's cost next to its timestamp and still fills exactly
+// the bar's width.
+func TestPickerTimelineShowsRunCost(t *testing.T) {
+	const width = 80
+	history := []session.Execution{
+		{Timestamp: time.Date(2026, 5, 29, 12, 0, 0, 0, time.UTC), Duration: 2 * time.Second},
+		{Timestamp: time.Date(2026, 5, 29, 12, 0, 1, 0, time.UTC), Duration: 3 * time.Second},
+	}
+	out := renderPickerTimeline(session.NewMemoryHistory(history...), 1, -1, width)
+	if w := lipgloss.Width(out); w != width {
+		t.Errorf("rendered width=%d want %d", w, width)
+	}
+	if !strings.Contains(out, "12:00:01 3s") {
+		t.Errorf("selected run's cost missing: %q", out)
+	}
+}
+
+// 'M' leads the bar title with the history's size against -history-bytes, and turns it off.
+func TestBarTitleMemoryStat(t *testing.T) {
+	m := New(Config{Command: "x", Interval: time.Second, MaxHistoryBytes: 256 << 20})
+	m = feed(t, m, tea.WindowSizeMsg{Width: 80, Height: 10})
+	m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: "a\nb\n"}})
+	if strings.Contains(m.barTitle(), "hist ") {
+		t.Fatalf("stat shown before 'M': %q", m.barTitle())
+	}
+	m = pressKey(t, m, 'M')
+	want := "hist 1 · " + binaryBytes(m.session.History.Bytes()) + "/256MiB · x"
+	if got := m.barTitle(); got != want {
+		t.Errorf("barTitle=%q want %q", got, want)
+	}
+	m = pressKey(t, m, 'M')
+	if got := m.barTitle(); got != "x" {
+		t.Errorf("after second 'M' barTitle=%q", got)
+	}
+}
+
+// atCounter is a History that counts the executions read back whole.
+type atCounter struct {
+	session.History
+	n int
+}
+
+func (h *atCounter) At(i int) session.Execution {
+	h.n++
+	return h.History.At(i)
+}
+
+// The bar and the picker timeline show a run's cost and timeout from its metadata alone, so
+// redrawing them never reads back or rebuilds an output.
+func TestBarReadsNoOutput(t *testing.T) {
+	m := New(Config{Command: "x", Interval: time.Second})
+	m = feed(t, m, tea.WindowSizeMsg{Width: 80, Height: 10})
+	m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: "a\n", Duration: 2 * time.Second, TimedOut: true, ExitCode: -1}})
+	h := &atCounter{History: m.session.History}
+	m.session.History = h
+	title := m.barTitle()
+	if !strings.HasPrefix(title, timeoutMark) || !strings.Contains(title, "2s") {
+		t.Errorf("barTitle=%q, want the timeout mark and the run's cost", title)
+	}
+	if out := renderPickerTimeline(h, 0, -1, 80); !strings.Contains(out, " 2s") {
+		t.Errorf("picker lacks the run's cost: %q", out)
+	}
+	if h.n != 0 {
+		t.Errorf("At called %d times", h.n)
+	}
+}
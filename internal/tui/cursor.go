@@ -46,11 +46,11 @@ func (c Cursor) Following(historyLen int) bool {
 	return historyLen == 0 || c.idx == historyLen-1
 }
 
-// AfterEvict shifts the cursor down by 1, clamping at 0, after a MaxHistory eviction
-// removed slot 0. Keeps a non-tail viewer reading the same frame; clamps to 0 when the
-// frame they were on was the one evicted.
-func (c Cursor) AfterEvict() Cursor {
-	return Cursor{idx: max(0, c.idx-1)}
+// AfterEvict shifts the cursor down by n, clamping at 0, after an eviction removed the n
+// oldest slots. Keeps a non-tail viewer reading the same frame; clamps to 0 when the frame
+// they were on was evicted.
+func (c Cursor) AfterEvict(n int) Cursor {
+	return Cursor{idx: max(0, c.idx-n)}
 }
 
 // ToTail returns a cursor at the last frame of a history of length n. Equivalent to
@@ -40,17 +40,19 @@ func TestCursorFollowing(t *testing.T) {
 
 func TestCursorAfterEvict(t *testing.T) {
 	cases := []struct {
-		in, want int
+		in, n, want int
 	}{
-		{-1, 0}, // noCursor clamps to 0 after eviction (rare; usually only fires when valid)
-		{0, 0},  // the frame they were on was evicted
-		{1, 0},
-		{4, 3},
+		{-1, 1, 0}, // noCursor clamps to 0 after eviction (rare; usually only fires when valid)
+		{0, 1, 0},  // the frame they were on was evicted
+		{1, 1, 0},
+		{4, 1, 3},
+		{4, 3, 1}, // a byte limit can evict several at once
+		{2, 3, 0},
 	}
 	for _, c := range cases {
-		got := cursorAt(c.in).AfterEvict()
+		got := cursorAt(c.in).AfterEvict(c.n)
 		if got.Index() != c.want {
-			t.Errorf("cursorAt(%d).AfterEvict().Index() = %d, want %d", c.in, got.Index(), c.want)
+			t.Errorf("cursorAt(%d).AfterEvict(%d).Index() = %d, want %d", c.in, c.n, got.Index(), c.want)
 		}
 	}
 }
@@ -6,6 +6,10 @@
 package diffrender
 
 import (
+	"slices"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/charmbracelet/x/ansi"
 	"github.com/charmbracelet/x/cellbuf"
 
@@ -13,25 +17,187 @@ import (
 	"github.com/ivoronin/wch/internal/tui/overlay"
 )
 
-// Render turns lines (the diff of the ANSI-stripped new output, one Line per new line in
-// order) into a styled string by overlaying onto styledOutput (the raw, styled new output):
-// changed cells get fg as their foreground, the command's background/attributes are
-// preserved, and SGR state carried across lines is honoured. styledOutput's rows align with
-// lines by index.
+// ghostStyle is what a deleted line's cells get: dimmed and struck through, with no colour
+// of its own so it reads as "was here" on any theme.
+var ghostStyle = cellbuf.Style{Attrs: cellbuf.FaintAttr | cellbuf.StrikethroughAttr}
+
+// noteStyle is what a numeric-delta note gets: dimmed, so it reads as commentary next to
+// the command's own text rather than as part of it.
+var noteStyle = cellbuf.Style{Attrs: cellbuf.FaintAttr}
+
+// movedUnderline marks a moved row: a dotted underline in the highlight colour, quiet enough
+// that a re-sorted table does not flash like a fully changed one.
+const movedUnderline = cellbuf.DottedUnderline
+
+// Render turns lines (the diff of the ANSI-stripped new output, in order) into a styled
+// string by overlaying onto styledOutput (the raw, styled new output): changed cells get fg
+// as their foreground, the command's background/attributes are preserved, and SGR state
+// carried across lines is honoured. styledOutput's rows align by index with the lines that
+// are not diff.LineDeleted.
+//
+// LineDeleted entries (from diff.Alignment.LinesWithDeleted) are the ghost-row mode: each is
+// spliced into the output at its position as a plain row of the old text, rendered with
+// ghostStyle. Lines without deletions render exactly as before. LineMoved rows keep the
+// highlight on their changed spans and get a movedUnderline across their text.
 func Render(lines []diff.Line, styledOutput string, fg ansi.Color) string {
+	return renderDiff(lines, styledOutput, fg, false)
+}
+
+// RenderDeltas is Render with the numeric deltas of changed and moved rows (diff.Deltas)
+// shown inline, each right after the field it belongs to ("1532 (+17)"), rendered with
+// noteStyle.
+func RenderDeltas(lines []diff.Line, styledOutput string, fg ansi.Color) string {
+	return renderDiff(lines, styledOutput, fg, true)
+}
+
+func renderDiff(lines []diff.Line, styledOutput string, fg ansi.Color, deltas bool) string {
 	if len(lines) == 0 {
 		return ""
 	}
-	return overlay.Walk(styledOutput, overlay.MaxDisplayWidth(styledOutput), len(lines), func(buf *cellbuf.Buffer) {
+	base := withGhostRows(lines, styledOutput)
+	var notes [][]bool
+	if deltas {
+		lines, base, notes = withDeltas(lines, base)
+	}
+	return overlay.Walk(base, overlay.MaxDisplayWidth(base), len(lines), func(buf *cellbuf.Buffer) {
 		for y, ln := range lines {
+			if ln.Kind == diff.LineDeleted {
+				ghostRow(buf, y, ansi.StringWidth(ln.Text))
+				continue
+			}
 			highlightRow(buf, y, ln, fg)
+			if ln.Kind == diff.LineMoved {
+				movedRow(buf, y, ansi.StringWidth(ln.Text), fg)
+			}
+			if y < len(notes) && notes[y] != nil {
+				paintRunes(buf, y, notes[y], func(c *cellbuf.Cell) { c.Style = noteStyle })
+			}
 		}
 	})
 }
 
+// withGhostRows returns styledOutput with the text of every LineDeleted entry inserted as a
+// row at its index in lines. Ghost rows carry no escape sequences, so SGR state opened on a
+// real row flows through them unchanged to the next real row; ghostRow then replaces
+// whatever style the ghost cells inherited.
+func withGhostRows(lines []diff.Line, styledOutput string) string {
+	rows := strings.Split(styledOutput, "\n")
+	if len(rows) == len(lines) {
+		return styledOutput // no deletions
+	}
+	out := make([]string, 0, len(lines))
+	ri := 0
+	for _, ln := range lines {
+		switch {
+		case ln.Kind == diff.LineDeleted:
+			out = append(out, ln.Text)
+		case ri < len(rows):
+			out = append(out, rows[ri])
+			ri++
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// insertion is text to insert into a row after its first at visible runes.
+type insertion struct {
+	at   int
+	text string
+}
+
+// withDeltas inserts the delta note of every field that has one into its row of base and
+// into a copy of lines (as an unchanged span, so it gets no highlight), and returns them
+// with, per row, the runes of its notes (nil for a row without any). The notes are plain
+// text; renderDiff restyles whatever SGR state they inherited from the row with noteStyle.
+func withDeltas(lines []diff.Line, base string) ([]diff.Line, string, [][]bool) {
+	rows := strings.Split(base, "\n")
+	var out []diff.Line
+	var notes [][]bool
+	for y, ln := range lines {
+		deltas := diff.Deltas(ln)
+		if deltas == nil || y >= len(rows) {
+			continue
+		}
+		if out == nil {
+			out, notes = slices.Clone(lines), make([][]bool, len(lines))
+		}
+		var spans []diff.Span
+		var ins []insertion
+		var marks []bool
+		at := 0 // visible runes of the row so far
+		for i, sp := range ln.Spans {
+			n := utf8.RuneCountInString(sp.Text)
+			spans, marks, at = append(spans, sp), append(marks, make([]bool, n)...), at+n
+			if deltas[i] == "" {
+				continue
+			}
+			note := " (" + deltas[i] + ")"
+			spans, ins = append(spans, diff.Span{Text: note}), append(ins, insertion{at, note})
+			for range utf8.RuneCountInString(note) {
+				marks = append(marks, true)
+			}
+		}
+		var text strings.Builder
+		for _, sp := range spans {
+			text.WriteString(sp.Text)
+		}
+		out[y].Text, out[y].Spans = text.String(), spans
+		rows[y], notes[y] = insertRunes(rows[y], ins), marks
+	}
+	if out == nil {
+		return lines, base, nil
+	}
+	return out, strings.Join(rows, "\n"), notes
+}
+
+// insertRunes inserts each of ins into row, a styled row, before the visible rune following
+// its first at ones (after the escape sequences that precede that rune), or at the row's
+// end. ins is ordered by at.
+func insertRunes(row string, ins []insertion) string {
+	var b strings.Builder
+	var state byte
+	at := 0
+	for row != "" {
+		seq, _, n, newState := ansi.DecodeSequence(row, state, nil)
+		state = newState
+		if visible := utf8.RuneCountInString(ansi.Strip(seq)); visible > 0 {
+			for len(ins) > 0 && ins[0].at <= at {
+				b.WriteString(ins[0].text)
+				ins = ins[1:]
+			}
+			at += visible
+		}
+		b.WriteString(seq)
+		row = row[n:]
+	}
+	for _, in := range ins {
+		b.WriteString(in.text)
+	}
+	return b.String()
+}
+
+// ghostRow restyles the first width cells of row y (the ghost text; the padding out to the
+// buffer width stays blank rather than struck through) with ghostStyle.
+func ghostRow(buf *cellbuf.Buffer, y, width int) {
+	for x := 0; x < min(width, buf.Width()); x++ {
+		if c := buf.Cell(x, y); c != nil && c.Width > 0 {
+			c.Style = ghostStyle
+		}
+	}
+}
+
+// movedRow underlines the first width cells of row y (the row's text) with movedUnderline
+// in fg, leaving the rest of each cell's style as the command and highlightRow set it.
+func movedRow(buf *cellbuf.Buffer, y, width int, fg ansi.Color) {
+	for x := 0; x < min(width, buf.Width()); x++ {
+		if c := buf.Cell(x, y); c != nil && c.Width > 0 {
+			c.Style.UlStyle = movedUnderline
+			c.Style.Ul = fg
+		}
+	}
+}
+
 // highlightRow sets fg on the cells of row y that correspond to changed visible runes of ln.
-// Cells are walked left-to-right, each consuming its grapheme's runes (base + combining), to
-// stay aligned with the diff's rune-indexed spans.
 func highlightRow(buf *cellbuf.Buffer, y int, ln diff.Line, fg ansi.Color) {
 	if ln.Kind == diff.LineEqual {
 		return // unchanged: keep the command's styling, no highlight
@@ -44,7 +210,7 @@ func highlightRow(buf *cellbuf.Buffer, y int, ln diff.Line, fg ansi.Color) {
 		for i := range changed {
 			changed[i] = true
 		}
-	case diff.LineChanged:
+	case diff.LineChanged, diff.LineMoved:
 		off := 0
 		for _, s := range ln.Spans {
 			n := len([]rune(s.Text))
@@ -57,22 +223,29 @@ func highlightRow(buf *cellbuf.Buffer, y int, ln diff.Line, fg ansi.Color) {
 		}
 	}
 
+	paintRunes(buf, y, changed, func(c *cellbuf.Cell) { c.Style.Fg = fg })
+}
+
+// paintRunes calls paint on the cells of row y that hold a rune i with marked[i] set. Cells
+// are walked left-to-right, each consuming its grapheme's runes (base + combining), to stay
+// aligned with the diff's rune-indexed spans.
+func paintRunes(buf *cellbuf.Buffer, y int, marked []bool, paint func(*cellbuf.Cell)) {
 	ri := 0
-	for x := 0; x < buf.Width() && ri < len(changed); x++ {
+	for x := 0; x < buf.Width() && ri < len(marked); x++ {
 		c := buf.Cell(x, y)
 		if c == nil || c.Width == 0 {
 			continue // padding or the continuation column of a wide rune: not a visible rune
 		}
 		cnt := 1 + len(c.Comb)
 		hot := false
-		for i := ri; i < ri+cnt && i < len(changed); i++ {
-			if changed[i] {
+		for i := ri; i < ri+cnt && i < len(marked); i++ {
+			if marked[i] {
 				hot = true
 				break
 			}
 		}
 		if hot {
-			c.Style.Fg = fg
+			paint(c)
 		}
 		ri += cnt
 	}
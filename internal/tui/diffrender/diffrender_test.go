@@ -13,6 +13,10 @@ var testFg = ansi.RGBColor{R: 0x2E, G: 0x7D, B: 0x32}
 
 const greenFg = "38;2;46;125;50" // truecolor SGR params for testFg (foreground)
 
+const ghostSGR = "\x1b[2;9m" // faint + strikethrough
+
+const movedSGR = "4:4;58;" + "2;46;125;50" // dotted underline, underline colour testFg
+
 // render runs the real path: diff on stripped text, overlay onto the styled new output.
 func render(old, neu string) string {
 	a := diff.Align(ansi.Strip(old), ansi.Strip(neu))
@@ -96,3 +100,81 @@ func TestRenderColorOnlyChangeIsNotADiff(t *testing.T) {
 		t.Errorf("new color not shown, raw=%q", body)
 	}
 }
+
+// renderGhosts is render with deleted lines spliced back in as ghost rows.
+func renderGhosts(old, neu string) string {
+	a := diff.Align(ansi.Strip(old), ansi.Strip(neu))
+	return Render(a.LinesWithDeleted(), neu, testFg)
+}
+
+func TestRenderGhostRowAtOldPosition(t *testing.T) {
+	body := renderGhosts("a\ngone\nc", "a\nc")
+	if got := ansi.Strip(body); got != "a\ngone\nc" {
+		t.Fatalf("visible=%q want %q\nraw=%q", got, "a\ngone\nc", body)
+	}
+	rows := strings.Split(body, "\n")
+	if !strings.Contains(rows[1], ghostSGR) {
+		t.Errorf("ghost row not faint+struck through, raw=%q", rows[1])
+	}
+	if strings.Contains(rows[0], ghostSGR) || strings.Contains(rows[2], ghostSGR) {
+		t.Errorf("strikethrough leaked onto live rows, raw=%q", body)
+	}
+}
+
+// Colour carried across lines still reaches the next live row past a ghost row.
+func TestRenderGhostRowKeepsCarriedColor(t *testing.T) {
+	body := renderGhosts("\x1b[31mfoo\ngone\nbar\x1b[0m", "\x1b[31mfoo\nbar\x1b[0m")
+	rows := strings.Split(body, "\n")
+	if len(rows) != 3 || !strings.Contains(rows[2], "31") {
+		t.Errorf("red not carried past the ghost row, raw=%q", body)
+	}
+}
+
+// Without deletions the ghost mode renders exactly like the plain mode.
+func TestRenderGhostModeNoDeletionsUnchanged(t *testing.T) {
+	if a, b := render("a b", "a c"), renderGhosts("a b", "a c"); a != b {
+		t.Errorf("ghost mode differs without deletions:\n%q\n%q", a, b)
+	}
+}
+
+// A moved row gets the dotted underline and no full-row highlight; the rows it moved past
+// are untouched.
+func TestRenderMovedRowMarked(t *testing.T) {
+	body := render("h\na 1\nb 2", "h\nb 2\na 1")
+	rows := strings.Split(body, "\n")
+	if len(rows) != 3 {
+		t.Fatalf("rows=%d want 3, raw=%q", len(rows), body)
+	}
+	if !strings.Contains(rows[2], movedSGR) {
+		t.Errorf("moved row not marked, raw=%q", rows[2])
+	}
+	if strings.Contains(rows[2], greenFg) {
+		t.Errorf("unchanged moved row highlighted, raw=%q", rows[2])
+	}
+	if rows[1] != "b 2" {
+		t.Errorf("row passed over restyled: %q", rows[1])
+	}
+}
+
+// A delta follows its value, dimmed, without inheriting the row's colour or its highlight;
+// the rest of the row keeps its styling and rows without one are untouched.
+func TestRenderDeltas(t *testing.T) {
+	neu := "\x1b[31mrx 1532\x1b[0m pkts\ntx 9"
+	a := diff.Align("rx 1515 pkts\ntx 9", ansi.Strip(neu))
+	body := RenderDeltas(a.Lines(), neu, testFg)
+	rows := strings.Split(body, "\n")
+	if got := strings.TrimRight(ansi.Strip(rows[0]), " "); got != "rx 1532 (+17) pkts" {
+		t.Fatalf("visible=%q want %q, raw=%q", got, "rx 1532 (+17) pkts", rows[0])
+	}
+	_, rest, _ := strings.Cut(rows[0], "1532")
+	note, _, _ := strings.Cut(rest, "pkts")
+	if !strings.Contains(note, "2m") || strings.Contains(note, "31") || strings.Contains(note, greenFg) {
+		t.Errorf("note not dimmed, or carries the row's colour or highlight, raw=%q", rows[0])
+	}
+	if before, _, _ := strings.Cut(rows[0], "1532"); !strings.Contains(before, greenFg) {
+		t.Errorf("changed value lost its highlight, raw=%q", rows[0])
+	}
+	if strings.TrimRight(ansi.Strip(rows[1]), " ") != "tx 9" {
+		t.Errorf("row without a note changed: %q", rows[1])
+	}
+}
@@ -2,10 +2,12 @@ package tui
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/x/ansi"
 
 	"github.com/ivoronin/wch/internal/diff"
+	"github.com/ivoronin/wch/internal/runner"
 	"github.com/ivoronin/wch/internal/session"
 	"github.com/ivoronin/wch/internal/tui/diffrender"
 	"github.com/ivoronin/wch/internal/tui/scrollview"
@@ -20,34 +22,112 @@ import (
 type FrameViewModel struct {
 	scrollview.Scrollview
 	session *session.Session
+	align   diff.Options
+	// baseline is the pinned history index Frame diffs against instead of the previous
+	// frame, or -1 when none is pinned or the pinned frame has been evicted.
+	baseline int
+	// pinned is a copy of the baseline frame, taken as soon as it is in the history, so
+	// diffs stay against it after history rotation evicts it; nil when none is pinned or it
+	// has not run yet.
+	pinned *session.Execution
 }
 
 // newFrameViewModel constructs the type with a zero-sized viewport; geometry comes from
-// the first WindowSizeMsg via SetSize (promoted from the embedded scrollview).
-func newFrameViewModel(s *session.Session) FrameViewModel {
+// the first WindowSizeMsg via SetSize (promoted from the embedded scrollview). align tunes
+// both the diff highlight and the anchor mapping.
+func newFrameViewModel(s *session.Session, align diff.Options) FrameViewModel {
 	return FrameViewModel{
 		Scrollview: scrollview.NewScrollview(0, 0),
 		session:    s,
+		align:      align,
+		baseline:   -1,
 	}
 }
 
-// Frame renders the styled body for history index i: command output, optional diff
-// highlights against the previous recorded frame (when diffEnabled), and an exit-code
-// annotation for non-zero exits. Out-of-range i returns "" so callers can treat it as
-// "nothing to display" without a separate predicate.
-func (f *FrameViewModel) Frame(i int, diffEnabled bool) string {
-	if i < 0 || i >= len(f.session.History) {
+// Baseline returns the pinned history index, ok=false when diffs are against the previous
+// frame or against a pinned frame that is no longer in the history (see Pinned).
+func (f *FrameViewModel) Baseline() (int, bool) { return f.baseline, f.baseline >= 0 }
+
+// Pinned returns the frame diffs are taken against, evicted or not; ok=false when none is
+// pinned or the pinned index has no frame yet.
+func (f *FrameViewModel) Pinned() (session.Execution, bool) {
+	if f.pinned == nil {
+		return session.Execution{}, false
+	}
+	return *f.pinned, true
+}
+
+// Pin makes history index i the baseline every frame is diffed against, so changes
+// accumulate from it instead of resetting on each tick; -1 unpins. i may run ahead of the
+// history (-baseline first pins 0 before the first execution lands).
+func (f *FrameViewModel) Pin(i int) {
+	f.baseline, f.pinned = i, nil
+	f.hold()
+}
+
+// AfterRecord follows a recorded execution that rotated the n oldest slots out: a pinned
+// baseline shifts down by n, as Cursor.AfterEvict does. One that was itself evicted leaves
+// the history but not the diff, which keeps comparing against its copy. A baseline pinned
+// ahead of the history is copied once its frame lands.
+func (f *FrameViewModel) AfterRecord(n int) {
+	if f.baseline >= 0 {
+		if f.baseline -= n; f.baseline < 0 {
+			f.baseline = -1
+		}
+	}
+	f.hold()
+}
+
+// hold copies the baseline frame once it is in the history.
+func (f *FrameViewModel) hold() {
+	if f.pinned == nil && f.baseline >= 0 && f.baseline < f.session.History.Len() {
+		e := f.session.History.At(f.baseline)
+		f.pinned = &e
+	}
+}
+
+// Frame renders the styled body for history index i under prefs: command output, optional
+// diff highlights (prefs.Diff) against the previous recorded frame or, when one is pinned,
+// the baseline (which itself shows no highlights, and is diffed against even once
+// evicted), with the compared frame's deleted lines spliced back in as ghost rows
+// (prefs.Ghosts) and changed numbers annotated with their delta (prefs.Deltas), and an
+// exit-code annotation for non-zero exits (a timeout one for runs killed at -timeout).
+// Out-of-range i returns "" so callers can treat it as "nothing to display" without a
+// separate predicate.
+func (f *FrameViewModel) Frame(i int, prefs Preferences) string {
+	if i < 0 || i >= f.session.History.Len() {
 		return ""
 	}
-	exec := f.session.History[i]
+	exec := f.session.History.At(i)
 	output := exec.Output()
 	body := output
-	if diffEnabled && i > 0 {
-		align := diff.Align(ansi.Strip(f.session.History[i-1].Output()), ansi.Strip(output))
-		body = diffrender.Render(align.Lines(), output, insertFg)
+	var base *session.Execution
+	if pinned, ok := f.Pinned(); ok {
+		if f.baseline != i {
+			base = &pinned
+		}
+	} else if i > 0 {
+		prevExec := f.session.History.At(i - 1)
+		base = &prevExec
+	}
+	if prefs.Diff && base != nil {
+		align := f.align.Align(ansi.Strip(base.Output()), ansi.Strip(output))
+		lines := align.Lines()
+		if prefs.Ghosts {
+			lines = align.LinesWithDeleted()
+		}
+		if prefs.Deltas {
+			body = diffrender.RenderDeltas(lines, output, insertFg)
+		} else {
+			body = diffrender.Render(lines, output, insertFg)
+		}
 	}
 	if exec.Error != nil && exec.ExitCode != 0 {
 		annot := errorStyle.Render(fmt.Sprintf("Exit code: %d", exec.ExitCode))
+		if exec.TimedOut {
+			// "Timed out after 10s", from the runner's "timed out after 10s".
+			annot = timeoutStyle.Render("Timed out" + strings.TrimPrefix(exec.Error.Error(), runner.ErrTimeout.Error()))
+		}
 		if body == "" {
 			body = annot
 		} else {
@@ -65,6 +145,13 @@ func (f *FrameViewModel) Frame(i int, diffEnabled bool) string {
 // while the cursor (historyIndex) advances or moves: per-exec dispatch and
 // per-cursor-move.
 //
+// Ghost rows (Preferences.Ghosts) need no special casing here: a ghost carries the text of
+// the row it replaces, so the alignment pairs it with that row in the previous body and the
+// anchor holds still when a row above the viewport disappears; on the following refresh the
+// ghost itself is an ordinary deletion and MapLine shifts the anchor up past it. Toggling
+// ghosts (or the diff under them) on the same frame goes through here too, the ghosts being
+// plain insertions or deletions against the body before.
+//
 // Trade-off note: when called as part of a per-exec repaint, this re-derives
 // the diff alignment that Frame's diff highlight already computed once for the
 // same pair of frames. The dedup gate in Model.dispatchExec keeps duplicate
@@ -78,7 +165,7 @@ func (f *FrameViewModel) ShowAnchored(newBody, prevBody string) {
 
 	var newOffset int
 	if !atTop && !atBottom {
-		anchor := diff.Align(ansi.Strip(prevBody), ansi.Strip(newBody))
+		anchor := f.align.Align(ansi.Strip(prevBody), ansi.Strip(newBody))
 		newOffset = anchor.MapLine(f.YOffset())
 	}
 
@@ -1,11 +1,15 @@
 package tui
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
 
+	"github.com/ivoronin/wch/internal/runner"
 	"github.com/ivoronin/wch/internal/session"
 )
 
@@ -60,8 +64,8 @@ func TestHistoryHighlightsSurviveRefresh(t *testing.T) {
 
 	plain := m
 	plain.prefs.Diff = false
-	before := m.frames.Frame(1, m.prefs.Diff)
-	if before == plain.frames.Frame(1, plain.prefs.Diff) {
+	before := m.frames.Frame(1, m.prefs)
+	if before == plain.frames.Frame(1, plain.prefs) {
 		t.Fatalf("frame 1 should be highlighted (diff vs frame 0); body=%q", before)
 	}
 
@@ -74,7 +78,7 @@ func TestHistoryHighlightsSurviveRefresh(t *testing.T) {
 	if got := m.frames.YOffset(); got != off {
 		t.Errorf("YOffset moved to %d, want %d", got, off)
 	}
-	if after := m.frames.Frame(1, m.prefs.Diff); after != before {
+	if after := m.frames.Frame(1, m.prefs); after != before {
 		t.Errorf("highlights reset after refresh:\n before=%q\n after =%q", before, after)
 	}
 }
@@ -95,3 +99,160 @@ func TestStickyBottom(t *testing.T) {
 		t.Errorf("sticky bottom: expected to follow the tail, offset=%d", m.frames.YOffset())
 	}
 }
+
+// With ghost rows on, a row deleted above the viewport is shown as a ghost in its old place,
+// so the anchored row stays put; the refresh after that drops the ghost and the anchor shifts
+// up past it.
+func TestAnchorWithGhostRows(t *testing.T) {
+	names := podNames(20)
+	m := New(Config{Command: "x", Interval: time.Second, DiffEnabled: true, ShowGhosts: true})
+	m = feed(t, m, tea.WindowSizeMsg{Width: 40, Height: 10})
+	m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: podTable("5m", names)}})
+	m.frames.SetYOffset(5)
+
+	without := append(append([]string{}, names[:1]...), names[2:]...) // pod-02 deleted
+	m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: podTable("6m", without)}})
+	if got := m.frames.YOffset(); got != 5 {
+		t.Errorf("offset with ghost row=%d want 5 (ghost keeps the rows below in place)", got)
+	}
+
+	m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: podTable("7m", without)}})
+	if got := m.frames.YOffset(); got != 4 {
+		t.Errorf("offset after ghost dropped=%d want 4", got)
+	}
+}
+
+// Turning ghost rows on while scrolled down inserts the ghost of a row deleted above the
+// viewport; the row under the top edge stays there, and turning them off again (or the diff
+// that carries them) puts the offset back.
+func TestGhostToggleKeepsAnchor(t *testing.T) {
+	names := podNames(20)
+	m := New(Config{Command: "x", Interval: time.Second, DiffEnabled: true})
+	m = feed(t, m, tea.WindowSizeMsg{Width: 40, Height: 10})
+	m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: podTable("5m", names)}})
+	without := append(append([]string{}, names[:1]...), names[2:]...) // pod-02 deleted
+	m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: podTable("6m", without)}})
+	m.frames.SetYOffset(5)
+	top := func() string { return strings.Fields(ansi.Strip(m.frames.View()))[0] }
+	want := top()
+
+	m = pressKey(t, m, 'g')
+	if got := m.frames.YOffset(); got != 6 || top() != want {
+		t.Errorf("ghosts on: offset=%d top=%q, want 6 and %q", got, top(), want)
+	}
+	m = pressKey(t, m, 'd')
+	if got := m.frames.YOffset(); got != 5 || top() != want {
+		t.Errorf("diff off under ghosts: offset=%d top=%q, want 5 and %q", got, top(), want)
+	}
+	m = pressKey(t, m, 'd')
+	m = pressKey(t, m, 'g')
+	if got := m.frames.YOffset(); got != 5 || top() != want {
+		t.Errorf("ghosts off: offset=%d top=%q, want 5 and %q", got, top(), want)
+	}
+}
+
+// With deltas on, each changed age is followed by its delta; unchanged header rows get
+// none.
+func TestFrameDeltaNotes(t *testing.T) {
+	names := podNames(2)
+	m := New(Config{Command: "x", Interval: time.Second, DiffEnabled: true, ShowDeltas: true})
+	m = feed(t, m, tea.WindowSizeMsg{Width: 40, Height: 10})
+	m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: podTable("5m3s", names)}})
+	m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: podTable("5m40s", names)}})
+
+	rows := strings.Split(ansi.Strip(m.frames.Frame(1, m.prefs)), "\n")
+	if strings.Contains(rows[0], "(") {
+		t.Errorf("header annotated: %q", rows[0])
+	}
+	for _, r := range rows[1:] {
+		if r != "" && !strings.HasSuffix(strings.TrimRight(r, " "), "5m40s (+37s)") {
+			t.Errorf("row %q lacks its delta note", r)
+		}
+	}
+}
+
+// With the first frame pinned, every later frame is diffed against it, so a change made two
+// ticks ago stays highlighted; the baseline frame itself has no highlights.
+func TestFrameDiffsAgainstPinnedBaseline(t *testing.T) {
+	m := New(Config{Command: "x", Interval: time.Second, DiffEnabled: true, BaselineFirst: true})
+	m = feed(t, m, tea.WindowSizeMsg{Width: 40, Height: 10})
+	for _, out := range []string{"a 1\nb 1", "a 2\nb 1", "a 2\nb 2"} {
+		m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: out}})
+	}
+	rows := strings.Split(m.frames.Frame(2, m.prefs), "\n")
+	if !strings.Contains(rows[0], "\x1b[") {
+		t.Errorf("row changed since the baseline not highlighted: %q", rows[0])
+	}
+	if got := m.frames.Frame(0, m.prefs); strings.Contains(got, "\x1b[") {
+		t.Errorf("baseline frame highlighted: %q", got)
+	}
+	if !strings.HasPrefix(m.barTitle(), "base ") {
+		t.Errorf("bar title %q lacks the baseline timestamp", m.barTitle())
+	}
+}
+
+// The pin key pins the frame at the cursor and unpins it on a second press; an eviction
+// keeps the baseline on the same frame.
+func TestPinBaselineKey(t *testing.T) {
+	m := New(Config{Command: "x", Interval: time.Second, DiffEnabled: true, MaxHistory: 2})
+	m = feed(t, m, tea.WindowSizeMsg{Width: 40, Height: 10})
+	m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: "a"}})
+	m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: "b"}})
+
+	m = pressKey(t, m, 'm')
+	if b, ok := m.frames.Baseline(); !ok || b != 1 {
+		t.Fatalf("baseline=%d,%v want 1,true", b, ok)
+	}
+	m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: "c"}})
+	if b, _ := m.frames.Baseline(); b != 0 || m.session.History.At(b).Stdout != "b" {
+		t.Errorf("baseline after eviction=%d, want 0 (still frame \"b\")", b)
+	}
+
+	m = m.withCursor(0)
+	m = pressKey(t, m, 'm')
+	if _, ok := m.frames.Baseline(); ok {
+		t.Errorf("second press on the baseline did not unpin it")
+	}
+}
+
+// A pinned frame that history rotation evicts stays the baseline: later frames are still
+// diffed against it, not against whatever frame is now the oldest.
+func TestEvictedBaselineStillDiffed(t *testing.T) {
+	m := New(Config{Command: "x", Interval: time.Second, DiffEnabled: true, BaselineFirst: true, MaxHistory: 2})
+	m = feed(t, m, tea.WindowSizeMsg{Width: 40, Height: 10})
+	for _, out := range []string{"a 1\nb 1", "a 2\nb 1", "a 2\nb 2", "a 2\nb 3"} {
+		m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: out}})
+	}
+	if _, ok := m.frames.Baseline(); ok {
+		t.Errorf("evicted baseline still has a history index")
+	}
+	if b, ok := m.frames.Pinned(); !ok || b.Stdout != "a 1\nb 1" {
+		t.Fatalf("pinned=%q,%v want the first frame", b.Stdout, ok)
+	}
+	rows := strings.Split(m.frames.Frame(1, m.prefs), "\n")
+	if !strings.Contains(rows[0], "\x1b[") {
+		t.Errorf("row changed since the evicted baseline not highlighted: %q", rows[0])
+	}
+	if rows = strings.Split(m.frames.Frame(0, m.prefs), "\n"); !strings.Contains(rows[0], "\x1b[") {
+		t.Errorf("oldest surviving frame treated as the baseline: %q", rows[0])
+	}
+	if !strings.HasPrefix(m.barTitle(), "base ") {
+		t.Errorf("bar title %q lacks the baseline timestamp", m.barTitle())
+	}
+}
+
+// A timed-out run gets its own annotation and bar mark instead of the exit-code one.
+func TestFrameTimeoutAnnotation(t *testing.T) {
+	m := newSizedModel(t, "a")
+	m = feed(t, m, execResultMsg{exec: session.Execution{
+		Stdout: "a", ExitCode: -1, TimedOut: true,
+		Error: fmt.Errorf("%w after 5s", runner.ErrTimeout),
+	}})
+	body := ansi.Strip(m.frames.Frame(1, m.prefs))
+	if !strings.HasSuffix(body, "Timed out after 5s") || strings.Contains(body, "Exit code") {
+		t.Errorf("body=%q, want a timeout annotation", body)
+	}
+	if !strings.HasPrefix(m.barTitle(), timeoutMark) {
+		t.Errorf("bar title %q lacks the timeout mark", m.barTitle())
+	}
+}
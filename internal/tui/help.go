@@ -28,7 +28,7 @@ func helpSections() []helpSection {
 	return []helpSection{
 		{"Global", []helpBinding{
 			{"q", "quit"},
-			{"t", "toggle status bar"},
+			{"t M", "status bar/memory stat"},
 			{"h", "this help"},
 			{"↑↓", "scroll up/down"},
 			{"←→", "scroll left/right"},
@@ -38,6 +38,9 @@ func helpSections() []helpSection {
 		}},
 		{"View", []helpBinding{
 			{"d", "toggle diff"},
+			{"g", "toggle deleted lines"},
+			{"+", "toggle numeric deltas"},
+			{"m", "pin/unpin diff baseline"},
 			{"p", "pause"},
 			{"r", "record"},
 			{"/", "search"},
@@ -47,6 +50,7 @@ func helpSections() []helpSection {
 		{"Picker", []helpBinding{
 			{"←→", "frame ±1"},
 			{"Home End", "first/last"},
+			{"j", "jump to time"},
 			{"Enter b", "confirm"},
 			{"Esc", "back to view"},
 		}},
@@ -63,12 +67,18 @@ func helpSections() []helpSection {
 	}
 }
 
+// paneHelpSection documents Multi's own bindings; its help overlay appends it.
+var paneHelpSection = helpSection{"Panes", []helpBinding{
+	{"Tab Shift+Tab", "next/previous pane"},
+	{"T", "tiles/tabs"},
+}}
+
 // renderHelpPanel composes the centered help overlay: a two-column layout (Global+View on
-// the left, Picker+Search+Input on the right) wrapped in a rounded border with no fill. A
-// dismiss tip ("h to close") is embedded in the bottom border, centered, so it doesn't eat
-// vertical space inside the panel.
-func renderHelpPanel() string {
-	sections := helpSections()
+// the left, Picker+Search+Input and any extra sections on the right) wrapped in a rounded
+// border with no fill. A dismiss tip ("h to close") is embedded in the bottom border,
+// centered, so it doesn't eat vertical space inside the panel.
+func renderHelpPanel(extra ...helpSection) string {
+	sections := append(helpSections(), extra...)
 	body := lipgloss.JoinHorizontal(lipgloss.Top,
 		renderHelpColumn(sections[:2]),
 		helpColumnGap,
@@ -40,20 +40,29 @@ var navKeys = struct {
 }
 
 // commonKeys are intercepted with identical semantics in both viewState and pickerState:
-// toggle diff/pause, start/stop recording, open search. Held once to avoid duplicating
-// the bindings (and the matching switch arms) across both handlers.
+// toggle diff/ghost rows/deltas/pause/memory stat, pin the baseline, start/stop recording,
+// open search. Held once to avoid duplicating the bindings (and the matching switch arms)
+// across both handlers.
 var commonKeys = struct {
-	ToggleDiff key.Binding
-	Pause      key.Binding
-	Record     key.Binding
-	Search     key.Binding
-	Escape     key.Binding
+	ToggleDiff   key.Binding
+	ToggleGhosts key.Binding
+	ToggleDeltas key.Binding
+	ToggleMemory key.Binding
+	PinBaseline  key.Binding
+	Pause        key.Binding
+	Record       key.Binding
+	Search       key.Binding
+	Escape       key.Binding
 }{
-	ToggleDiff: key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "diff")),
-	Pause:      key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "pause")),
-	Record:     key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "record")),
-	Search:     key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search")),
-	Escape:     key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
+	ToggleDiff:   key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "diff")),
+	ToggleGhosts: key.NewBinding(key.WithKeys("g"), key.WithHelp("g", "deleted")),
+	ToggleDeltas: key.NewBinding(key.WithKeys("+"), key.WithHelp("+", "deltas")),
+	ToggleMemory: key.NewBinding(key.WithKeys("M"), key.WithHelp("M", "memory")),
+	PinBaseline:  key.NewBinding(key.WithKeys("m"), key.WithHelp("m", "baseline")),
+	Pause:        key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "pause")),
+	Record:       key.NewBinding(key.WithKeys("r"), key.WithHelp("r", "record")),
+	Search:       key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "search")),
+	Escape:       key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "back")),
 }
 
 // viewKeys are viewState-specific bindings (entering the picker).
@@ -63,12 +72,14 @@ var viewKeys = struct {
 	Picker: key.NewBinding(key.WithKeys("b"), key.WithHelp("b", "history")),
 }
 
-// pickerKeys are pickerState-specific bindings (confirming a selection). Enter and the
-// picker-entry key (b) are symmetric aliases.
+// pickerKeys are pickerState-specific bindings (confirming a selection, jumping to a time).
+// Enter and the picker-entry key (b) are symmetric aliases.
 var pickerKeys = struct {
 	Confirm key.Binding
+	Jump    key.Binding
 }{
 	Confirm: key.NewBinding(key.WithKeys("enter", "b"), key.WithHelp("enter", "confirm")),
+	Jump:    key.NewBinding(key.WithKeys("j"), key.WithHelp("j", "jump to time")),
 }
 
 // searchKeys are searchState-specific bindings. n/p navigate matches. '/' and Esc reuse
@@ -81,6 +92,18 @@ var searchKeys = struct {
 	NavPrev: key.NewBinding(key.WithKeys("N"), key.WithHelp("N", "prev")),
 }
 
+// paneKeys are Multi's bindings. It takes them only while the focused pane is in view or
+// the picker, so they still reach a search query or the record prompt.
+var paneKeys = struct {
+	Next   key.Binding
+	Prev   key.Binding
+	Layout key.Binding
+}{
+	Next:   key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "next pane")),
+	Prev:   key.NewBinding(key.WithKeys("shift+tab"), key.WithHelp("shift+tab", "previous pane")),
+	Layout: key.NewBinding(key.WithKeys("T"), key.WithHelp("T", "tiles/tabs")),
+}
+
 // minimalBarBindings is the canonical bottom-bar trailer: state-specific extras followed
 // by the always-visible {Help, Quit} pair.
 func minimalBarBindings(extras ...key.Binding) []key.Binding {
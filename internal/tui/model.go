@@ -2,27 +2,53 @@ package tui
 
 import (
 	"context"
+	"errors"
+	"sort"
 	"time"
 
 	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2/compat"
 
+	"github.com/ivoronin/wch/internal/diff"
+	"github.com/ivoronin/wch/internal/events"
+	"github.com/ivoronin/wch/internal/exitcond"
 	"github.com/ivoronin/wch/internal/recording"
 	"github.com/ivoronin/wch/internal/runner"
+	"github.com/ivoronin/wch/internal/schedule"
 	"github.com/ivoronin/wch/internal/session"
+	"github.com/ivoronin/wch/internal/trigger"
 	"github.com/ivoronin/wch/internal/tui/notify"
 )
 
 // Config holds TUI configuration.
 type Config struct {
-	Command        string
-	Interval       time.Duration
-	DiffEnabled    bool
-	ShowStatus     bool
-	NotifyOnChange bool
-	AutoStart      *recording.AutoStartRequest // non-nil: start a recording to this path at launch
-	MaxHistory     int                         // executions retained in memory; 0 = unlimited
+	Command         string
+	Interval        time.Duration
+	DiffEnabled     bool
+	ShowGhosts      bool         // deleted lines as ghost rows (under DiffEnabled)
+	ShowDeltas      bool         // changed numbers annotated with their delta (under DiffEnabled)
+	BaselineFirst   bool         // diff every frame against the first one, as if it were pinned
+	Align           diff.Options // row identity and ignored text for diffing, dedup, and anchoring
+	ShowStatus      bool
+	NotifyOnChange  bool
+	Argv            []string                    // non-nil: run this argv without a shell (Command is its quoted form)
+	Shell           string                      // interpreter for Command; "" = sh
+	PTY             bool                        // run the command under a pseudo-terminal sized to the viewport
+	Timeout         time.Duration               // kill a run after this long; 0 = never
+	Stream          bool                        // keep one invocation running and snapshot its output
+	StreamLines     int                         // under Stream, snapshot only the last lines; 0 = all
+	AutoStart       *recording.AutoStartRequest // non-nil: start a recording to this path at launch
+	MaxHistory      int                         // executions retained in memory; 0 = unlimited
+	MaxHistoryBytes int64                       // output bytes retained; 0 = unlimited
+	Spool           int64                       // > 0: keep this many output bytes in memory, older ones in a temporary file
+	Compress        bool                        // gzip recordings whatever their file name
+	Exit            exitcond.Conditions         // quit on its own once one of these fires
+	Events          *events.Sink                // NDJSON event stream; nil = none
+	Schedule        schedule.Scheduler          // when to run; nil = every Interval
+	Precise         bool                        // tick at the schedule's times, not after each result
+	Overlap         schedule.Policy             // what a Precise tick or a Trigger does while a run is in flight
+	Trigger         trigger.Source              // changes that start a run; ticks then poll only when Interval or Schedule is set
 }
 
 // Model is the Bubble Tea model. Domain (session, runner), infrastructure (viewport,
@@ -32,6 +58,7 @@ type Model struct {
 	// Core
 	session *session.Session
 	runner  *runner.Runner
+	events  *events.Sink // nil-safe; nil when no event stream is configured
 
 	// Infrastructure: frames owns the rendered viewport (Frame + ShowAnchored + embedded
 	// scrollview navigation). State.Body says "what to display"; frames decides "how".
@@ -41,16 +68,33 @@ type Model struct {
 	// Cross-state toggles
 	prefs Preferences
 
-	// Runtime
-	executing bool
+	// Runtime: gate tracks the runs in flight (and, under fixed-rate ticks or a trigger,
+	// the overlap policy and its skipped ticks). nextRun is when the pending tick fires; the
+	// bar shows it when sched is anything but a plain interval. Ticks are fixed-rate (they
+	// schedule their successor as they fire, not when the result arrives) under -precise or
+	// alongside a trigger, and off altogether for a trigger without a poll interval.
+	sched     schedule.Scheduler
+	ticking   bool
+	fixedRate bool
+	gate      schedule.Gate
+	nextRun   time.Time
+	trigger   trigger.Source
+	cause     string // what started the latest run: "tick" or the trigger's event
+	queued    string // what the run queued by the gate is for
 	width     int
 	height    int
 	ready     bool
+	label     string // leads the bar title when Multi hosts the model as a pane
 
 	// Persistence wiring
 	flow      *recording.Flow
 	autoStart *recording.AutoStartRequest
 
+	// Exit conditions and the one that ended the session, if any (read by main via
+	// ExitReason after p.Run returns).
+	exit       exitcond.Conditions
+	exitReason exitcond.Reason
+
 	// History cursor for view/picker. dispatchExec advances it per state.FollowsTail.
 	cursor Cursor
 
@@ -70,24 +114,65 @@ func (m Model) push(level notify.Level, msg string) (Model, tea.Cmd) {
 	return m, cmd
 }
 
+// scheduler returns cfg.Schedule, or the plain Interval's when there is none.
+func (cfg Config) scheduler() schedule.Scheduler {
+	if cfg.Schedule != nil {
+		return cfg.Schedule
+	}
+	return schedule.ForInterval(cfg.Interval, cfg.Precise)
+}
+
 // New creates a live TUI model that watches cfg.Command. If cfg.AutoStart is non-nil,
 // recording to that path starts during Init.
 func New(cfg Config) Model {
 	sess := session.NewSession(cfg.Command, cfg.Interval)
 	sess.MaxHistory = cfg.MaxHistory
+	sess.MaxHistoryBytes = cfg.MaxHistoryBytes
+	if cfg.Spool > 0 {
+		sess.History = session.NewSpoolHistory("", cfg.Spool)
+	}
+	sess.Argv = cfg.Argv
+	r := runner.New(cfg.Command)
+	if cfg.Argv != nil {
+		r = runner.NewArgv(cfg.Argv)
+	}
+	r.Shell = cfg.Shell
+	r.PTY = cfg.PTY
+	r.Timeout = cfg.Timeout
+	trig := cfg.Trigger
+	if cfg.Stream {
+		// New output is snapshotted as it arrives, ticks permitting.
+		r.Stream, r.StreamLines = true, cfg.StreamLines
+		sig := trigger.NewSignal("output", trigger.Debounce)
+		r.OnOutput, trig = sig.Fire, sig
+	}
+	if len(cfg.Align.Ignore) > 0 {
+		sess.Mask = cfg.Align.Masked
+	}
+	flow := recording.New(sess)
+	flow.Compress = cfg.Compress
 	return Model{
 		session: sess,
-		runner:  runner.New(cfg.Command),
-		flow:    recording.New(sess),
-		frames:  newFrameViewModel(sess),
+		runner:  r,
+		events:  cfg.Events,
+		flow:    flow,
+		frames:  cfg.frameViewModel(sess),
 		cursor:  noCursor(),
 		state:   viewState{},
 		prefs: Preferences{
 			Diff:      cfg.DiffEnabled,
+			Ghosts:    cfg.ShowGhosts,
+			Deltas:    cfg.ShowDeltas,
 			StatusBar: cfg.ShowStatus,
 			OSNotify:  cfg.NotifyOnChange,
 		},
 		autoStart: cfg.AutoStart,
+		exit:      cfg.Exit,
+		sched:     cfg.scheduler(),
+		ticking:   trig == nil || cfg.Interval > 0 || cfg.Schedule != nil,
+		fixedRate: cfg.Precise || trig != nil,
+		trigger:   trig,
+		gate:      schedule.Gate{Policy: cfg.Overlap},
 		notify:    notify.New(),
 	}
 }
@@ -99,11 +184,13 @@ func NewReplay(cfg Config, s *session.Session) Model {
 		session: s,
 		runner:  nil,
 		flow:    recording.New(s),
-		frames:  newFrameViewModel(s),
-		cursor:  cursorAtTail(len(s.History)),
+		frames:  cfg.frameViewModel(s),
+		cursor:  cursorAtTail(s.History.Len()),
 		state:   viewState{},
 		prefs: Preferences{
 			Diff:      cfg.DiffEnabled,
+			Ghosts:    cfg.ShowGhosts,
+			Deltas:    cfg.ShowDeltas,
 			StatusBar: cfg.ShowStatus,
 			OSNotify:  false,
 		},
@@ -111,14 +198,40 @@ func NewReplay(cfg Config, s *session.Session) Model {
 	}
 }
 
+// frameViewModel builds the frame model New and NewReplay share, with the first frame
+// pinned as baseline under BaselineFirst.
+func (cfg Config) frameViewModel(s *session.Session) FrameViewModel {
+	f := newFrameViewModel(s, cfg.Align)
+	if cfg.BaselineFirst {
+		f.Pin(0)
+	}
+	return f
+}
+
 func (m Model) isLive() bool { return m.runner != nil }
 
-// Cleanup finalises any resources held by the Model (today: an active recording). Bubble
-// Tea v2 short-circuits Model.Update on QuitMsg — Update is never called for that message —
-// so the Model has no chance to flush its own teardown. main.go calls Cleanup after p.Run
-// returns. Idempotent: flow.Stop is a no-op when no recording is active.
+// Cleanup finalises any resources held by the Model: an active recording, the file a
+// replayed history reads from, a streaming command still running, and the trigger (an
+// -on-change watcher's descriptor and goroutines). Bubble Tea v2 short-circuits
+// Model.Update on QuitMsg — Update is never called for that message — so the Model has no
+// chance to flush its own teardown.
+// main.go calls Cleanup after p.Run returns. Idempotent: flow.Stop is a no-op when no
+// recording is active, as are History.Close, runner.Close and a second trigger Close.
 func (m Model) Cleanup() error {
-	return m.flow.Stop()
+	if m.runner != nil {
+		m.runner.Close()
+	}
+	var trigErr error
+	if m.trigger != nil {
+		trigErr = m.trigger.Close()
+	}
+	return errors.Join(m.flow.Stop(), m.session.History.Close(), trigErr)
+}
+
+// ExitReason reports which exit condition ended the session, or exitcond.None when the
+// user quit. main.go maps it to the process exit status.
+func (m Model) ExitReason() exitcond.Reason {
+	return m.exitReason
 }
 
 // Init starts the TUI. Replay returns nil — no tick is ever scheduled. Live mode kicks off
@@ -130,13 +243,14 @@ func (m Model) Init() tea.Cmd {
 	}
 	tick := func() tea.Msg { return tickMsg{} }
 	if m.autoStart == nil {
-		return tea.Batch(bgQuery, tick)
+		return tea.Batch(bgQuery, tick, m.awaitTrigger())
 	}
-	path := m.autoStart.Path
+	req := *m.autoStart
 	return tea.Batch(
 		bgQuery,
 		tick,
-		func() tea.Msg { return autoStartRecordingMsg{path: path} },
+		m.awaitTrigger(),
+		func() tea.Msg { return autoStartRecordingMsg{req: req} },
 	)
 }
 
@@ -156,11 +270,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tickMsg:
 		m2, cmd := m.handleTick()
 		return m2, tea.Batch(cmd, notifyCmd)
+	case triggerMsg:
+		m2, cmd := m.handleTrigger(msg)
+		return m2, tea.Batch(cmd, notifyCmd)
 	case execResultMsg:
 		m2, cmd := m.dispatchExec(msg)
 		return m2, tea.Batch(cmd, notifyCmd)
 	case autoStartRecordingMsg:
-		m2, cmd, _ := m.startRecording(msg.path)
+		m2, cmd, _ := m.autoStartRecording(msg.req)
 		return m2, tea.Batch(cmd, notifyCmd)
 	case tea.BackgroundColorMsg:
 		compat.HasDarkBackground = msg.IsDark()
@@ -278,26 +395,43 @@ func (m Model) handleGlobalKey(msg tea.KeyPressMsg) (Model, tea.Cmd) {
 // output; search owns its frozen body; input is transparent and delegates both freeze and
 // follow policy to its prev.
 func (m Model) dispatchExec(msg execResultMsg) (Model, tea.Cmd) {
-	m.executing = false
+	keep, runQueued := m.gate.Finish(msg.exec.Timestamp)
+	if !keep {
+		// Superseded by a later-started run already shown; fixed-rate ticks keep coming
+		// on their own, so there is nothing to schedule.
+		return m, nil
+	}
 
 	prev, _ := m.state.Body(m)
 	prior := m.cursor
 	wasAtTail := m.isFollowing()
+	// The event stream diffs against the previous history frame, captured before recording
+	// because a MaxHistory of 1 evicts it.
+	var prevOutput string
+	if n := m.session.History.Len(); n > 0 {
+		last := m.session.History.At(n - 1)
+		prevOutput = last.Output()
+	}
 	added, evicted, err := m.session.RecordIfChanged(msg.exec)
+	m.events.Frame(msg.seq, prevOutput, msg.exec, added)
+	if fb, ok := m.sched.(schedule.Feedback); ok {
+		fb.Observe(msg.exec.Failed(), added)
+	}
 	var cmds []tea.Cmd
 	if err != nil {
 		var c tea.Cmd
 		m, c = m.push(notify.LevelWarning, "Recording error: "+err.Error())
 		cmds = append(cmds, c)
 	}
-	// MaxHistory eviction shifted every slot index down by 1. For a non-tail viewer the
-	// cursor must follow so the user keeps reading the same frame (clamped to 0 when the
-	// frame they were on was the one evicted).
-	if evicted {
-		m.cursor = m.cursor.AfterEvict()
+	// Eviction shifted every slot index down by evicted. For a non-tail viewer the cursor
+	// must follow so the user keeps reading the same frame (clamped to 0 when the frame
+	// they were on was evicted); a pinned baseline follows too, or lives on as a copy.
+	if evicted > 0 {
+		m.cursor = m.cursor.AfterEvict(evicted)
 	}
+	m.frames.AfterRecord(evicted)
 	if added && m.state.FollowsTail(wasAtTail) {
-		m.cursor = m.cursor.ToTail(len(m.session.History))
+		m.cursor = m.cursor.ToTail(m.session.History.Len())
 	}
 	// Skip the full diff/anchor recomputation when nothing the viewport derives from has
 	// changed (duplicate tick: !added && cursor didn't move). Important for the wch
@@ -307,10 +441,24 @@ func (m Model) dispatchExec(msg execResultMsg) (Model, tea.Cmd) {
 	}
 	// prior.Valid() gates the very first frame (when there was no prior to compare against).
 	// This replaces the older len(History) > 1 gate, which was unreachable when MaxHistory==1.
-	if m.prefs.OSNotify && added && prior.Valid() {
+	changed := added && prior.Valid()
+	if m.prefs.OSNotify && changed {
 		cmds = append(cmds, sendNotification())
 	}
-	cmds = append(cmds, m.scheduleNextTick())
+	// A fired exit condition quits instead of scheduling the next tick. The frame that fired
+	// it has already been recorded above, so Cleanup's flow.Stop persists it.
+	if r := m.exit.Check(msg.exec, changed); r != exitcond.None {
+		m.exitReason = r
+		return m, tea.Batch(append(cmds, tea.Quit)...)
+	}
+	switch {
+	case runQueued:
+		m.gate.Start()
+		m.cause = m.queued
+		cmds = append(cmds, m.executeCmd())
+	case !m.fixedRate:
+		cmds = append(cmds, m.scheduleNextTick())
+	}
 	return m, tea.Batch(cmds...)
 }
 
@@ -371,15 +519,56 @@ func (m Model) repaintAnchored(prev string) Model {
 	return m
 }
 
-// handleTick processes the periodic execution tick.
+// handleTick processes the periodic execution tick. By default the next tick is scheduled
+// when the run's result arrives (dispatchExec); fixed-rate ticks schedule their successor
+// right away, and the gate's overlap policy decides whether one that finds a run in flight
+// starts another.
 func (m Model) handleTick() (Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	if m.fixedRate || m.prefs.Paused {
+		cmds = append(cmds, m.scheduleNextTick())
+	}
+	m, cmd := m.startRun("tick")
+	return m, tea.Batch(append(cmds, cmd)...)
+}
+
+// handleTrigger starts a run for a change the trigger reported, as a tick would, and waits
+// for the next one.
+func (m Model) handleTrigger(msg triggerMsg) (Model, tea.Cmd) {
+	m, cmd := m.startRun(msg.cause)
+	return m, tea.Batch(cmd, m.awaitTrigger())
+}
+
+// startRun starts a run for cause unless paused or the gate holds it back (queued runs
+// start from dispatchExec when the one in flight finishes).
+func (m Model) startRun(cause string) (Model, tea.Cmd) {
 	if m.prefs.Paused {
-		return m, m.scheduleNextTick()
+		return m, nil
+	}
+	if !m.gate.Tick() {
+		m.queued = cause
+		return m, nil
 	}
-	m.executing = true
+	m.gate.Start()
+	m.cause = cause
 	return m, m.executeCmd()
 }
 
+// awaitTrigger waits for the trigger's next event; nil without a trigger.
+func (m Model) awaitTrigger() tea.Cmd {
+	if m.trigger == nil {
+		return nil
+	}
+	events := m.trigger.Events()
+	return func() tea.Msg {
+		cause, ok := <-events
+		if !ok {
+			return nil
+		}
+		return triggerMsg{cause: cause}
+	}
+}
+
 // handleResize responds to terminal size changes. The viewport geometry is updated, then
 // the content is re-committed via the active state's Body (state-aware: searchState
 // re-overlays its captured body with the highlight; view/picker re-derive from
@@ -390,22 +579,33 @@ func (m Model) handleResize(msg tea.WindowSizeMsg) (Model, tea.Cmd) {
 	m.ready = true
 	m = m.withResizedScrollview()
 	m = m.repaint()
+	if m.isLive() {
+		// Under -pty the next run lays its output out for the new width.
+		m.runner.SetSize(m.width, m.frames.Height())
+	}
 	// ClearScreen forces a full terminal redraw, preventing visual artifacts in terminal
 	// multiplexers like Zellij that don't handle Bubble Tea's differential rendering
 	// correctly on resize.
 	return m, tea.ClearScreen
 }
 
-// executeCmd runs the command and returns the result as a message.
+// executeCmd runs the command and returns the result as a message. The event sink brackets
+// the run with its started/finished events (a no-op when none is configured).
 func (m Model) executeCmd() tea.Cmd {
 	return func() tea.Msg {
-		return execResultMsg{exec: m.runner.Execute(context.Background())}
+		exec, seq := m.events.Run(context.Background(), m.runner)
+		return execResultMsg{exec: exec, seq: seq}
 	}
 }
 
-// scheduleNextTick schedules the next execution tick.
-func (m Model) scheduleNextTick() tea.Cmd {
-	return tea.Tick(m.session.Interval, func(time.Time) tea.Msg {
+// scheduleNextTick schedules the next execution tick at the scheduler's next time and
+// remembers it for the bar. Without ticks (a trigger with no poll) it schedules nothing.
+func (m *Model) scheduleNextTick() tea.Cmd {
+	if !m.ticking {
+		return nil
+	}
+	m.nextRun = m.sched.Next(time.Now())
+	return tea.Tick(time.Until(m.nextRun), func(time.Time) tea.Msg {
 		return tickMsg{}
 	})
 }
@@ -424,7 +624,7 @@ func (m Model) withResizedScrollview() Model {
 // (e.g. pressing Right at the tail or Left at the head) short-circuit so we don't run a
 // full Strip+Align+SetContent for an identity re-render on every boundary keypress.
 func (m Model) withCursor(newIdx int) Model {
-	moved := m.cursor.Move(newIdx, len(m.session.History))
+	moved := m.cursor.Move(newIdx, m.session.History.Len())
 	if moved == m.cursor {
 		return m
 	}
@@ -433,9 +633,21 @@ func (m Model) withCursor(newIdx int) Model {
 	return m.repaintAnchored(prev)
 }
 
+// seekTime moves the cursor to the last frame taken at or before t (the first frame when
+// every one is later), or to the tail when tail is set. Multi keeps its panes at the moment
+// the focused pane shows with it.
+func (m Model) seekTime(t time.Time, tail bool) Model {
+	h := m.session.History
+	if tail {
+		return m.withCursor(h.Len() - 1)
+	}
+	i := sort.Search(h.Len(), func(i int) bool { return h.Timestamp(i).After(t) })
+	return m.withCursor(i - 1)
+}
+
 // isFollowing returns true if viewing the latest history item.
 func (m Model) isFollowing() bool {
-	return m.cursor.Following(len(m.session.History))
+	return m.cursor.Following(m.session.History.Len())
 }
 
 // sendNotification sends an OSC9 notification via raw terminal output.
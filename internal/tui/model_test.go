@@ -6,6 +6,11 @@ import (
 	"time"
 
 	tea "charm.land/bubbletea/v2"
+
+	"github.com/ivoronin/wch/internal/exitcond"
+	"github.com/ivoronin/wch/internal/schedule"
+	"github.com/ivoronin/wch/internal/session"
+	"github.com/ivoronin/wch/internal/trigger"
 )
 
 // NewReplay never schedules a tick and renders the latest frame after the first WindowSizeMsg.
@@ -32,7 +37,7 @@ func TestNewReplayInitNoTickAndRendersLatest(t *testing.T) {
 	if !m.ready {
 		t.Errorf("Model.ready should be true after WindowSizeMsg")
 	}
-	body := m.frames.Frame(m.cursor.Index(), m.prefs.Diff)
+	body := m.frames.Frame(m.cursor.Index(), m.prefs)
 	if !strings.Contains(body, "frame 2") {
 		t.Errorf("expected latest frame rendered; got %q", body)
 	}
@@ -51,3 +56,155 @@ func TestHandleKeyPriorityFallsThroughToGlobal(t *testing.T) {
 		t.Errorf("q-key cmd produced %T (%v), want tea.QuitMsg{}", msg, msg)
 	}
 }
+
+// A fired exit condition quits (tea.Quit instead of the next tick) and is reported through
+// ExitReason; the first frame never counts as a change.
+func TestExitOnChangeQuits(t *testing.T) {
+	m := New(Config{Command: "x", Interval: time.Second, Exit: exitcond.Conditions{OnChange: true}})
+	m = feed(t, m, tea.WindowSizeMsg{Width: 40, Height: 10})
+	m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: "a"}})
+	if m.ExitReason() != exitcond.None {
+		t.Fatalf("first frame fired %v", m.ExitReason())
+	}
+	m = feed(t, m, execResultMsg{exec: session.Execution{Stdout: "a"}})
+	if m.ExitReason() != exitcond.None {
+		t.Fatalf("duplicate frame fired %v", m.ExitReason())
+	}
+
+	next, cmd := m.Update(execResultMsg{exec: session.Execution{Stdout: "b"}})
+	if got := next.(Model).ExitReason(); got != exitcond.Changed {
+		t.Errorf("ExitReason=%v want %v", got, exitcond.Changed)
+	}
+	if !batchContains(cmd, tea.QuitMsg{}) {
+		t.Errorf("expected tea.Quit in the returned cmd")
+	}
+}
+
+// Under -precise a tick that finds a run in flight is skipped (the default policy), and the
+// count shows in the bar; the in-flight result is still recorded.
+func TestPreciseTickSkipsOverlap(t *testing.T) {
+	m := New(Config{Command: "x", Interval: time.Second, Precise: true})
+	m = feed(t, m, tea.WindowSizeMsg{Width: 80, Height: 10})
+	m = feed(t, m, tickMsg{})
+	m = feed(t, m, tickMsg{})
+	if m.gate.Running() != 1 || m.gate.Skipped() != 1 {
+		t.Fatalf("running=%d skipped=%d want 1 and 1", m.gate.Running(), m.gate.Skipped())
+	}
+	if !strings.HasPrefix(m.barTitle(), "1 skipped · ") {
+		t.Errorf("bar title %q lacks the skipped count", m.barTitle())
+	}
+	m = feed(t, m, execResultMsg{exec: session.Execution{Timestamp: time.Now(), Stdout: "a"}})
+	if m.gate.Running() != 0 || m.session.History.Len() != 1 {
+		t.Errorf("running=%d history=%d want 0 and 1", m.gate.Running(), m.session.History.Len())
+	}
+}
+
+// A -schedule other than a plain interval shows the next planned run in the bar.
+func TestScheduleShowsNextRun(t *testing.T) {
+	cron, err := schedule.ParseCron("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(Config{Command: "x", Schedule: cron})
+	m = feed(t, m, tea.WindowSizeMsg{Width: 80, Height: 10})
+	m = feed(t, m, execResultMsg{exec: session.Execution{Timestamp: time.Now(), Stdout: "a"}})
+	want := "next " + cron.Next(time.Now()).Format(timestampFmt) + " · "
+	if !strings.HasPrefix(m.barTitle(), want) {
+		t.Errorf("bar title %q, want prefix %q", m.barTitle(), want)
+	}
+
+	m = New(Config{Command: "x", Interval: time.Second})
+	m = feed(t, m, execResultMsg{exec: session.Execution{Timestamp: time.Now(), Stdout: "a"}})
+	if strings.Contains(m.barTitle(), "next ") {
+		t.Errorf("plain interval bar title %q shows the next run", m.barTitle())
+	}
+}
+
+// Under -backoff a repeated output doubles the interval, a change snaps it back, and the bar
+// shows the effective one.
+func TestBackoffInterval(t *testing.T) {
+	m := New(Config{Command: "x", Schedule: schedule.NewBackoff(time.Second, time.Minute)})
+	m = feed(t, m, tea.WindowSizeMsg{Width: 80, Height: 10})
+	for _, out := range []string{"a", "a", "a"} {
+		m = feed(t, m, execResultMsg{exec: session.Execution{Timestamp: time.Now(), Stdout: out}})
+	}
+	if !strings.HasPrefix(m.barTitle(), "every 4s · ") {
+		t.Errorf("bar title %q, want the backed-off interval", m.barTitle())
+	}
+	m = feed(t, m, execResultMsg{exec: session.Execution{Timestamp: time.Now(), Stdout: "b"}})
+	if !strings.HasPrefix(m.barTitle(), "every 1s · ") {
+		t.Errorf("bar title %q, want the base interval after a change", m.barTitle())
+	}
+}
+
+// fakeTrigger is a trigger.Source fed by the test.
+type fakeTrigger chan string
+
+func (f fakeTrigger) Events() <-chan string { return f }
+func (f fakeTrigger) Close() error          { return nil }
+
+// Cleanup closes the trigger, so its goroutines end with the session; a second Cleanup is a
+// no-op.
+func TestCleanupClosesTrigger(t *testing.T) {
+	sig := trigger.NewSignal("output", time.Millisecond)
+	m := New(Config{Command: "x", Trigger: sig})
+	if err := m.Cleanup(); err != nil {
+		t.Fatalf("Cleanup: %v", err)
+	}
+	select {
+	case _, ok := <-sig.Events():
+		if ok {
+			t.Error("event after Cleanup")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("trigger still open after Cleanup")
+	}
+	if err := m.Cleanup(); err != nil {
+		t.Errorf("second Cleanup: %v", err)
+	}
+}
+
+// A trigger event starts a run like a tick, and the bar names it; without a poll interval
+// the result schedules no tick, and a change during a run is queued.
+func TestTriggerStartsRun(t *testing.T) {
+	m := New(Config{Command: "x", Trigger: make(fakeTrigger), Overlap: schedule.Queue})
+	m = feed(t, m, tea.WindowSizeMsg{Width: 80, Height: 10})
+	m = feed(t, m, triggerMsg{cause: "src/main.go"})
+	if m.gate.Running() != 1 {
+		t.Fatalf("running=%d want 1", m.gate.Running())
+	}
+	m = feed(t, m, triggerMsg{cause: "src/util.go"})
+	if m.gate.Running() != 1 || m.gate.Skipped() != 0 {
+		t.Fatalf("running=%d skipped=%d want the second change queued", m.gate.Running(), m.gate.Skipped())
+	}
+	if !strings.HasPrefix(m.barTitle(), "via src/main.go · ") {
+		t.Errorf("bar title %q lacks the trigger", m.barTitle())
+	}
+	m = feed(t, m, execResultMsg{exec: session.Execution{Timestamp: time.Now(), Stdout: "a"}})
+	if m.gate.Running() != 1 || !strings.HasPrefix(m.barTitle(), "via src/util.go · ") {
+		t.Errorf("running=%d title %q, want the queued run started", m.gate.Running(), m.barTitle())
+	}
+	if cmd := m.scheduleNextTick(); cmd != nil {
+		t.Error("a trigger without -i must not schedule ticks")
+	}
+}
+
+// batchContains runs cmd (flattening tea.Batch) and reports whether any produced message
+// equals want. Every member is run, so only use it on a cmd with no tea.Tick inside (a tick
+// blocks for its full interval).
+func batchContains(cmd tea.Cmd, want tea.Msg) bool {
+	if cmd == nil {
+		return false
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, c := range msg {
+			if batchContains(c, want) {
+				return true
+			}
+		}
+		return false
+	default:
+		return msg == want
+	}
+}
@@ -0,0 +1,250 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/compat"
+
+	"github.com/ivoronin/wch/internal/exitcond"
+	"github.com/ivoronin/wch/internal/tui/helprender"
+	"github.com/ivoronin/wch/internal/tui/notify"
+)
+
+// Multi is the Bubble Tea model for several commands watched at once. Each pane is a whole
+// Model with its own session, runner and frames, laid out as tiles stacked top to bottom or
+// as tabs showing one pane at a time. Keys go to the focused pane; moving its cursor (the
+// picker, Esc back to the tail) moves every other pane to the frame it had at the same
+// moment.
+type Multi struct {
+	panes  []Model
+	focus  int
+	tabs   bool
+	help   bool
+	width  int
+	height int
+}
+
+// paneMsg carries a message a pane's command produced back to that pane. Multi wraps every
+// message that belongs to one pane (its ticks, triggers, results and notification expiries)
+// so that N panes' timers never cross; program-level messages (quit, raw output, terminal
+// queries) pass through unwrapped.
+type paneMsg struct {
+	pane int
+	msg  tea.Msg
+}
+
+// NewMulti creates a model hosting panes, focused on the first. panes must not be empty.
+func NewMulti(panes []Model) Multi {
+	m := Multi{panes: slices.Clone(panes)}
+	return m.withFocus(0)
+}
+
+// Cleanup finalises every pane (see Model.Cleanup).
+func (m Multi) Cleanup() error {
+	var errs []error
+	for _, p := range m.panes {
+		errs = append(errs, p.Cleanup())
+	}
+	return errors.Join(errs...)
+}
+
+// ExitReason reports the exit condition that ended the session, from the first pane whose
+// condition fired, or exitcond.None when the user quit.
+func (m Multi) ExitReason() exitcond.Reason {
+	for _, p := range m.panes {
+		if r := p.ExitReason(); r != exitcond.None {
+			return r
+		}
+	}
+	return exitcond.None
+}
+
+// Init starts every pane.
+func (m Multi) Init() tea.Cmd {
+	cmds := make([]tea.Cmd, len(m.panes))
+	for i, p := range m.panes {
+		cmds[i] = wrapCmd(i, p.Init())
+	}
+	return tea.Batch(cmds...)
+}
+
+// Update routes a pane's own messages back to it, lays the panes out on resize, takes the
+// pane keys, and hands everything else to the focused pane.
+func (m Multi) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case paneMsg:
+		return m.updatePane(msg.pane, msg.msg)
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m.layout()
+	case tea.KeyPressMsg:
+		return m.handleKey(msg)
+	case tea.BackgroundColorMsg:
+		compat.HasDarkBackground = msg.IsDark()
+		return m, nil
+	}
+	return m.updatePane(m.focus, msg)
+}
+
+// updatePane feeds msg to pane i. The pane slice is copied first: Multi is a value, and an
+// earlier copy must not see the update.
+func (m Multi) updatePane(i int, msg tea.Msg) (Multi, tea.Cmd) {
+	next, cmd := m.panes[i].Update(msg)
+	m.panes = slices.Clone(m.panes)
+	m.panes[i] = next.(Model)
+	return m, wrapCmd(i, cmd)
+}
+
+// layout gives each pane its share of the screen: an equal slice of the rows when tiled
+// (the first panes take the remainder), or all rows but the tab strip's.
+func (m Multi) layout() (Multi, tea.Cmd) {
+	n := len(m.panes)
+	cmds := make([]tea.Cmd, n)
+	for i := range m.panes {
+		h := m.height - 1
+		if !m.tabs {
+			h = m.height / n
+			if i < m.height%n {
+				h++
+			}
+		}
+		var cmd tea.Cmd
+		m, cmd = m.updatePane(i, tea.WindowSizeMsg{Width: m.width, Height: h})
+		cmds[i] = cmd
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// handleKey takes the pane keys (and h, for a help overlay that lists them) while the
+// focused pane is in view or the picker, and otherwise hands the key to the focused pane,
+// syncing the others when its cursor moved.
+func (m Multi) handleKey(msg tea.KeyPressMsg) (Multi, tea.Cmd) {
+	switch m.panes[m.focus].state.(type) {
+	case viewState, pickerState:
+		switch {
+		case key.Matches(msg, paneKeys.Next):
+			return m.withFocus(m.focus + 1), nil
+		case key.Matches(msg, paneKeys.Prev):
+			return m.withFocus(m.focus - 1), nil
+		case key.Matches(msg, paneKeys.Layout):
+			m.tabs = !m.tabs
+			return m.layout()
+		case key.Matches(msg, globalKeys.Help):
+			m.help = !m.help
+			return m, nil
+		}
+	}
+	prior := m.panes[m.focus].cursor
+	m, cmd := m.updatePane(m.focus, msg)
+	if m.panes[m.focus].cursor != prior {
+		m = m.synced()
+	}
+	return m, cmd
+}
+
+// synced moves every other pane to the frame it had when the focused pane's frame was taken,
+// or to its tail when the focused pane is at its own.
+func (m Multi) synced() Multi {
+	f := m.panes[m.focus]
+	i, ok := f.cursor.At()
+	if !ok {
+		return m
+	}
+	t, tail := f.session.History.Timestamp(i), f.isFollowing()
+	m.panes = slices.Clone(m.panes)
+	for j := range m.panes {
+		if j != m.focus {
+			m.panes[j] = m.panes[j].seekTime(t, tail)
+		}
+	}
+	return m
+}
+
+// withFocus focuses pane i (wrapping around) and relabels the panes so the bars show which
+// one has it.
+func (m Multi) withFocus(i int) Multi {
+	n := len(m.panes)
+	m.focus = (i%n + n) % n
+	m.panes = slices.Clone(m.panes)
+	for j := range m.panes {
+		label := fmt.Sprintf(" %d", j+1)
+		if j == m.focus {
+			label = boldKeep(fmt.Sprintf("▸%d", j+1))
+		}
+		m.panes[j].label = label
+	}
+	return m
+}
+
+// View renders the tiles top to bottom, or the tab strip over the focused pane, with the
+// help overlay (listing the pane keys) on top when toggled.
+func (m Multi) View() tea.View {
+	var content string
+	switch {
+	case m.width == 0:
+		content = "Initializing..."
+	case m.tabs:
+		content = m.tabStrip() + "\n" + m.panes[m.focus].render()
+	default:
+		parts := make([]string, len(m.panes))
+		for i, p := range m.panes {
+			parts[i] = p.render()
+		}
+		content = strings.Join(parts, "\n")
+	}
+	if m.help {
+		content = helprender.Overlay(content, renderHelpPanel(paneHelpSection), m.width, m.height)
+	}
+	v := tea.NewView(content)
+	v.AltScreen = true
+	v.WindowTitle = fmt.Sprintf("wch: %d commands", len(m.panes))
+	if !m.panes[0].isLive() {
+		v.WindowTitle = fmt.Sprintf("wch (replay): %d commands", len(m.panes))
+	}
+	return v
+}
+
+// tabStrip renders one tab per pane, numbered and named after its command, sharing the
+// width equally; the focused tab is highlighted as the picker's selection is.
+func (m Multi) tabStrip() string {
+	w := m.width / len(m.panes)
+	tabs := make([]string, len(m.panes))
+	for i, p := range m.panes {
+		style := pickerItemStyle
+		if i == m.focus {
+			style = pickerSelectedStyle
+		}
+		tabs[i] = style.Render(renderLeft(fmt.Sprintf(" %d %s", i+1, p.session.Command), w))
+	}
+	return lipgloss.NewStyle().Width(m.width).Background(barBg).Render(lipgloss.JoinHorizontal(lipgloss.Top, tabs...))
+}
+
+// wrapCmd tags the messages cmd produces that belong to pane i, descending into batches.
+func wrapCmd(i int, cmd tea.Cmd) tea.Cmd {
+	if cmd == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		switch msg := cmd().(type) {
+		case tea.BatchMsg:
+			out := make(tea.BatchMsg, len(msg))
+			for j, c := range msg {
+				out[j] = wrapCmd(i, c)
+			}
+			return out
+		case tickMsg, triggerMsg, execResultMsg, autoStartRecordingMsg:
+			return paneMsg{pane: i, msg: msg}
+		default:
+			if notify.Owns(msg) {
+				return paneMsg{pane: i, msg: msg}
+			}
+			return msg
+		}
+	}
+}
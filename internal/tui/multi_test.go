@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/ivoronin/wch/internal/schedule"
+	"github.com/ivoronin/wch/internal/session"
+)
+
+// replayAt builds a replay pane whose frames were taken at 12:00:00 + offset + i seconds.
+func replayAt(command string, offset time.Duration, n int) Model {
+	s := session.NewSession(command, time.Second)
+	for i := range n {
+		_, _, _ = s.RecordIfChanged(session.Execution{
+			Timestamp: time.Date(2026, 5, 30, 12, 0, i, 0, time.UTC).Add(offset),
+			Stdout:    command + " " + string(rune('a'+i)),
+		})
+	}
+	return NewReplay(Config{ShowStatus: true}, s)
+}
+
+func feedMulti(t *testing.T, m Multi, msg tea.Msg) Multi {
+	t.Helper()
+	next, _ := m.Update(msg)
+	return next.(Multi)
+}
+
+// Moving the focused pane's cursor in the picker moves the other panes to the frame they had
+// at that moment; going back to the tail (Esc from the view) takes them along.
+func TestMultiPickerSyncsByTimestamp(t *testing.T) {
+	m := NewMulti([]Model{replayAt("pods", 0, 3), replayAt("events", 500*time.Millisecond, 3)})
+	m = feedMulti(t, m, tea.WindowSizeMsg{Width: 60, Height: 20})
+
+	m = feedMulti(t, m, tea.KeyPressMsg{Code: 'b', Text: "b"})
+	m = feedMulti(t, m, tea.KeyPressMsg{Code: tea.KeyLeft})
+	if got := m.panes[0].cursor.Index(); got != 1 {
+		t.Fatalf("focused cursor=%d want 1", got)
+	}
+	if got := m.panes[1].cursor.Index(); got != 0 {
+		t.Errorf("synced cursor=%d want 0 (12:00:00.5 is the last frame by 12:00:01)", got)
+	}
+
+	m = feedMulti(t, m, tea.KeyPressMsg{Code: tea.KeyEscape}) // confirm, back to view
+	m = feedMulti(t, m, tea.KeyPressMsg{Code: tea.KeyEscape}) // jump to the live tail
+	if got := m.panes[1].cursor.Index(); got != 2 {
+		t.Errorf("after Esc: synced cursor=%d want 2 (tail)", got)
+	}
+}
+
+// Tab cycles the focus and the bars mark the focused pane; while the focused pane takes text
+// (the record prompt), Tab is not a pane key.
+func TestMultiFocus(t *testing.T) {
+	m := NewMulti([]Model{
+		New(Config{Command: "a", Interval: time.Second, ShowStatus: true}),
+		New(Config{Command: "b", Interval: time.Second, ShowStatus: true}),
+	})
+	m = feedMulti(t, m, tea.WindowSizeMsg{Width: 60, Height: 20})
+	m = feedMulti(t, m, tea.KeyPressMsg{Code: tea.KeyTab})
+	if m.focus != 1 {
+		t.Fatalf("focus=%d want 1", m.focus)
+	}
+	if !strings.Contains(m.panes[1].barTitle(), "▸2") || strings.Contains(m.panes[0].barTitle(), "▸") {
+		t.Errorf("labels: %q / %q", m.panes[0].barTitle(), m.panes[1].barTitle())
+	}
+	m = feedMulti(t, m, tea.KeyPressMsg{Code: tea.KeyTab, Mod: tea.ModShift})
+	if m.focus != 0 {
+		t.Fatalf("shift+tab: focus=%d want 0", m.focus)
+	}
+
+	m = feedMulti(t, m, tea.KeyPressMsg{Code: 'r', Text: "r"})
+	m = feedMulti(t, m, tea.KeyPressMsg{Code: tea.KeyTab})
+	if m.focus != 0 {
+		t.Errorf("Tab in the record prompt moved the focus to %d", m.focus)
+	}
+}
+
+// A pane's own messages come back wrapped for it: a result lands in the pane that ran,
+// not the focused one.
+func TestMultiRoutesPaneMessages(t *testing.T) {
+	m := NewMulti([]Model{
+		New(Config{Command: "a", Interval: time.Second}),
+		New(Config{Command: "b", Interval: time.Second}),
+	})
+	m = feedMulti(t, m, tea.WindowSizeMsg{Width: 60, Height: 20})
+
+	msg := wrapCmd(1, func() tea.Msg { return execResultMsg{exec: session.Execution{Stdout: "x"}} })()
+	if pm, ok := msg.(paneMsg); !ok || pm.pane != 1 {
+		t.Fatalf("wrapped %T %+v, want paneMsg for pane 1", msg, msg)
+	}
+	m = feedMulti(t, m, msg)
+	if m.panes[1].session.History.Len() != 1 || m.panes[0].session.History.Len() != 0 {
+		t.Errorf("histories: %d / %d, want 0 / 1", m.panes[0].session.History.Len(), m.panes[1].session.History.Len())
+	}
+	if msg := wrapCmd(0, tea.Quit)(); msg != (tea.QuitMsg{}) {
+		t.Errorf("quit wrapped as %T", msg)
+	}
+}
+
+// Under -backoff each pane adapts to its own results: a pane that keeps failing backs off
+// while one whose output keeps changing stays at the base interval.
+func TestMultiBackoffPerPane(t *testing.T) {
+	pane := func(command string) Model {
+		return New(Config{Command: command, Interval: time.Second, Schedule: schedule.NewBackoff(time.Second, 8*time.Second)})
+	}
+	m := NewMulti([]Model{pane("down"), pane("up")})
+	m = feedMulti(t, m, tea.WindowSizeMsg{Width: 60, Height: 20})
+	for i := range 4 {
+		m = feedMulti(t, m, paneMsg{pane: 0, msg: execResultMsg{exec: session.Execution{ExitCode: 1, Stderr: "refused"}}})
+		m = feedMulti(t, m, paneMsg{pane: 1, msg: execResultMsg{exec: session.Execution{Stdout: strings.Repeat("x", i+1)}}})
+	}
+	down := m.panes[0].sched.(*schedule.Backoff).Interval()
+	up := m.panes[1].sched.(*schedule.Backoff).Interval()
+	if down != 8*time.Second || up != time.Second {
+		t.Errorf("intervals: failing pane %v, changing pane %v; want 8s and 1s", down, up)
+	}
+}
+
+// Tiles share the rows; tabs give every pane all rows but the strip's.
+func TestMultiLayout(t *testing.T) {
+	m := NewMulti([]Model{replayAt("a", 0, 1), replayAt("b", 0, 1), replayAt("c", 0, 1)})
+	m = feedMulti(t, m, tea.WindowSizeMsg{Width: 40, Height: 20})
+	for i, want := range []int{7, 7, 6} {
+		if got := m.panes[i].height; got != want {
+			t.Errorf("tile %d height=%d want %d", i, got, want)
+		}
+	}
+	if got := strings.Count(m.View().Content, "\n") + 1; got != 20 {
+		t.Errorf("tiled view has %d rows, want 20", got)
+	}
+
+	m = feedMulti(t, m, tea.KeyPressMsg{Code: 'T', Text: "T"})
+	if !m.tabs || m.panes[2].height != 19 {
+		t.Fatalf("tabs=%v height=%d, want tabs with 19 rows", m.tabs, m.panes[2].height)
+	}
+	if got := strings.Count(m.View().Content, "\n") + 1; got != 20 {
+		t.Errorf("tabbed view has %d rows, want 20", got)
+	}
+}
@@ -108,3 +108,10 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 func (m Model) Active() bool {
 	return len(m.bubbles) > 0
 }
+
+// Owns reports whether msg is one of the messages Push's Cmd delivers, so a host running
+// several Models can route it back to the one that pushed it.
+func Owns(msg tea.Msg) bool {
+	_, ok := msg.(expireMsg)
+	return ok
+}
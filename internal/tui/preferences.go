@@ -4,12 +4,15 @@ package tui
 // as a single nested field on Model (m.prefs) so all read/write sites mention
 // the same prefix and the toggle set is visible at one declaration.
 //
-// CLI-derived preferences (Diff, StatusBar, OSNotify) are populated from
-// Config in New / NewReplay. Runtime-only toggles (Paused, HelpVisible)
+// CLI-derived preferences (Diff, Ghosts, Deltas, StatusBar, OSNotify) are populated from
+// Config in New / NewReplay. Runtime-only toggles (Paused, HelpVisible, Memory)
 // default to false.
 type Preferences struct {
 	Diff      bool // toggled by 'd'; controls renderFrame's diff overlay
+	Ghosts    bool // toggled by 'g'; deleted lines shown as ghost rows under Diff
+	Deltas    bool // toggled by '+'; changed numbers annotated with their delta under Diff
 	StatusBar bool // toggled by 't'; user side of barShown's OR with state.ShowsBar
+	Memory    bool // toggled by 'M'; barTitle leads with the history's size
 	// OSNotify gates the OSC9 ping on exec changes; set via -b at launch. Named for
 	// the OSC9 channel, not the trigger; cfg.NotifyOnChange maps here.
 	OSNotify    bool
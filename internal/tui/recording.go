@@ -18,9 +18,10 @@ const (
 )
 
 // autoStartRecordingMsg fires once during Init when AutoStart was non-nil: a deferred
-// flow.Start so the failure path (rare; the CLI already verified the file doesn't exist)
-// can surface a warning bubble instead of crashing the program.
-type autoStartRecordingMsg struct{ path string }
+// flow.Start (or flow.Attach, for a stream of a multi-stream recording) so the failure path
+// (rare; the CLI already verified the file doesn't exist) can surface a warning bubble
+// instead of crashing the program.
+type autoStartRecordingMsg struct{ req recording.AutoStartRequest }
 
 // newRecordInput builds the textinput for the record-filename prompt: bar-matched palette,
 // branded prompt label, value pre-filled, cursor parked at the end so Enter accepts the
@@ -36,7 +37,20 @@ func newRecordInput(initial string) textinput.Model {
 // notification bubble. ok reports whether the recording is now active. Shared by the
 // auto-start launch path and the interactive record-filename submit.
 func (m Model) startRecording(path string) (Model, tea.Cmd, bool) {
-	err := m.flow.Start(path)
+	return m.reportRecordingStart(path, m.flow.Start(path))
+}
+
+// autoStartRecording begins the recording AutoStart asked for.
+func (m Model) autoStartRecording(req recording.AutoStartRequest) (Model, tea.Cmd, bool) {
+	if req.Recorder != nil {
+		return m.reportRecordingStart(req.Path, m.flow.Attach(req.Recorder))
+	}
+	return m.startRecording(req.Path)
+}
+
+// reportRecordingStart translates the outcome of starting a recording at path into a
+// notification bubble.
+func (m Model) reportRecordingStart(path string, err error) (Model, tea.Cmd, bool) {
 	switch {
 	case err == nil:
 		m2, cmd := m.push(notify.LevelInfo, recordStartedMessage)
@@ -66,7 +80,11 @@ func (m Model) toggleRecord(from state) (Model, state, tea.Cmd) {
 		}
 		return m, from, cmd
 	}
-	return m.openInput(from, newRecordInput(recording.DefaultFilename(m.session.Command, time.Now())), applyRecordSubmit)
+	name := recording.DefaultFilename(m.session.Command, time.Now())
+	if m.flow.Compress {
+		name += ".gz"
+	}
+	return m.openInput(from, newRecordInput(name), applyRecordSubmit)
 }
 
 // applyRecordSubmit validates the typed path and either starts recording (popping back to
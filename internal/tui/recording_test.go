@@ -120,12 +120,12 @@ func TestSubmitInputStartsRecording(t *testing.T) {
 		t.Fatalf("recording.Load: %v", err)
 	}
 	want := []string{"alpha\n", "beta\n", "gamma\n"}
-	if len(loaded.History) != len(want) {
-		t.Fatalf("loaded len=%d want %d", len(loaded.History), len(want))
+	if loaded.History.Len() != len(want) {
+		t.Fatalf("loaded len=%d want %d", loaded.History.Len(), len(want))
 	}
 	for i, w := range want {
-		if loaded.History[i].Stdout != w {
-			t.Errorf("frame %d stdout=%q want %q", i, loaded.History[i].Stdout, w)
+		if loaded.History.At(i).Stdout != w {
+			t.Errorf("frame %d stdout=%q want %q", i, loaded.History.At(i).Stdout, w)
 		}
 	}
 }
@@ -253,7 +253,7 @@ func TestCleanupFinalizesRecording(t *testing.T) {
 	if err != nil {
 		t.Fatalf("recording.Load: %v", err)
 	}
-	if len(loaded.History) == 0 {
+	if loaded.History.Len() == 0 {
 		t.Errorf("expected at least one frame in the finalized file")
 	}
 }
@@ -7,17 +7,49 @@ import (
 	tea "charm.land/bubbletea/v2"
 )
 
-// handleCommonKey handles the diff/pause/record/search bindings shared by
-// viewState and pickerState. Returns handled=false if msg matches none of
-// them. Lives here (not in either state's file) because both states call it
-// and neither owns the shape.
+// handleCommonKey handles the diff/ghosts/deltas/memory/baseline/pause/record/search
+// bindings shared by viewState and pickerState. Returns handled=false if msg matches none
+// of them. Lives here (not in either state's file) because both states call it and neither
+// owns the shape.
 func (m Model) handleCommonKey(s state, msg tea.KeyPressMsg) (Model, state, tea.Cmd, bool) {
 	switch {
 	case key.Matches(msg, commonKeys.ToggleDiff):
-		// Same frame → anchor would be identity. Skip the diff.Align dance and re-render
-		// directly; scroll position stays put because viewport's offset is not touched.
+		// Same frame, so without ghost rows the anchor would be identity: re-render directly;
+		// scroll position stays put because viewport's offset is not touched. With them the
+		// diff brings its ghost rows along, so it is anchored like the ghost toggle.
+		if m.prefs.Ghosts {
+			prev, _ := m.state.Body(m)
+			m.prefs.Diff = !m.prefs.Diff
+			return m.repaintAnchored(prev), s, nil, true
+		}
 		m.prefs.Diff = !m.prefs.Diff
 		return m.repaint(), s, nil, true
+	case key.Matches(msg, commonKeys.ToggleGhosts):
+		// Same frame, but ghost rows appear or go: anchored against the body before, so ones
+		// above the viewport do not push the row under the top edge out of place.
+		prev, _ := m.state.Body(m)
+		m.prefs.Ghosts = !m.prefs.Ghosts
+		return m.repaintAnchored(prev), s, nil, true
+	case key.Matches(msg, commonKeys.ToggleDeltas):
+		// Notes trail their rows, so no line moves: a plain repaint.
+		m.prefs.Deltas = !m.prefs.Deltas
+		return m.repaint(), s, nil, true
+	case key.Matches(msg, commonKeys.ToggleMemory):
+		// Only the bar changes, and View renders it afresh.
+		m.prefs.Memory = !m.prefs.Memory
+		return m, s, nil, true
+	case key.Matches(msg, commonKeys.PinBaseline):
+		// Pins the frame at the cursor, or unpins it when it already is the baseline. Only
+		// highlights change, so a plain repaint.
+		i, ok := m.cursor.At()
+		if !ok {
+			return m, s, nil, true
+		}
+		if b, pinned := m.frames.Baseline(); pinned && b == i {
+			i = -1
+		}
+		m.frames.Pin(i)
+		return m.repaint(), s, nil, true
 	case key.Matches(msg, commonKeys.Pause):
 		m.prefs.Paused = !m.prefs.Paused
 		return m, s, nil, true
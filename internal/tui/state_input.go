@@ -132,7 +132,7 @@ func applySearchSubmit(m Model, s inputState) (Model, state, tea.Cmd) {
 	// case (no history yet) flows into the "no matches" branch below without a
 	// separate guard.
 	i, ok := m.cursor.At()
-	body := m.frames.Frame(i, m.prefs.Diff)
+	body := m.frames.Frame(i, m.prefs)
 	matches := findMatches(ansi.Strip(body), q)
 	if len(matches) == 0 {
 		var cmd tea.Cmd
@@ -148,7 +148,7 @@ func applySearchSubmit(m Model, s inputState) (Model, state, tea.Cmd) {
 		prev:     s.prev,
 	}
 	if ok {
-		next.captured = m.session.History[i].Timestamp
+		next.captured = m.session.History.Timestamp(i)
 	}
 	m = m.repaintWith(next, next.snap())
 	return m, next, nil
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"strings"
 	"time"
 
 	"charm.land/bubbles/v2/key"
@@ -8,6 +9,7 @@ import (
 	"charm.land/lipgloss/v2"
 
 	"github.com/ivoronin/wch/internal/session"
+	"github.com/ivoronin/wch/internal/tui/notify"
 )
 
 // timestampLen is the display width of a formatted timestamp.
@@ -25,7 +27,7 @@ var (
 // the viewport shows the frame at the cursor position.
 func (pickerState) Body(m Model) (string, bool) {
 	i, ok := m.cursor.At()
-	return m.frames.Frame(i, m.prefs.Diff), ok
+	return m.frames.Frame(i, m.prefs), ok
 }
 
 // Timestamp returns the timestamp of the frame at the cursor position -- the picker's
@@ -35,7 +37,7 @@ func (pickerState) Timestamp(m Model) (time.Time, bool) {
 	if !ok {
 		return time.Time{}, false
 	}
-	return m.session.History[i].Timestamp, true
+	return m.session.History.Timestamp(i), true
 }
 
 // ShowsBar returns true because the picker timeline IS the bar — without it
@@ -55,7 +57,11 @@ func (pickerState) FollowsTail(wasAtTail bool) bool { return wasAtTail }
 // RenderBar renders the timeline-style bar replacing the status bar: a left
 // and right column of timestamps surrounding the centered selected timestamp.
 func (pickerState) RenderBar(m Model) string {
-	return renderPickerTimeline(m.session.History, m.cursor.Index(), m.width)
+	b, ok := m.frames.Baseline()
+	if !ok {
+		b = -1
+	}
+	return renderPickerTimeline(m.session.History, m.cursor.Index(), b, m.width)
 }
 
 // Handle processes a key for pickerState. Common bindings (diff/pause/record/
@@ -76,27 +82,89 @@ func (s pickerState) Handle(m Model, msg tea.KeyPressMsg) (Model, state, tea.Cmd
 	case key.Matches(msg, navKeys.Home), key.Matches(msg, navKeys.ScrollLeft):
 		return m.withCursor(0), s, nil, true
 	case key.Matches(msg, navKeys.End), key.Matches(msg, navKeys.ScrollRight):
-		return m.withCursor(len(m.session.History) - 1), s, nil, true
+		return m.withCursor(m.session.History.Len() - 1), s, nil, true
+	case key.Matches(msg, pickerKeys.Jump):
+		if !m.cursor.Valid() {
+			return m, s, nil, true
+		}
+		newM, st, cmd := m.openInput(s, newBarInput(jumpPromptLabel), applyJumpSubmit)
+		return newM, st, cmd, true
 	}
 	return m, s, nil, false
 }
 
-// renderPickerTimeline is the pure-data picker bar renderer: given the history slice, the
-// selected index, and the available width, it builds the horizontal timeline strip.
-func renderPickerTimeline(history []session.Execution, selected, width int) string {
-	if len(history) == 0 {
+// jumpPromptLabel is the prompt of the picker's jump-to-time input.
+const jumpPromptLabel = "Jump to: "
+
+// applyJumpSubmit moves the cursor to the last frame taken at or before the typed time
+// (the first frame when every one is later) and returns to the picker. An empty value pops
+// silently; one that does not parse is reported.
+func applyJumpSubmit(m Model, s inputState) (Model, state, tea.Cmd) {
+	v := strings.TrimSpace(s.input.Value())
+	if v == "" {
+		return m, s.prev, nil
+	}
+	t, ok := parseJumpTime(v, m.session.History.Timestamp(m.cursor.Index()))
+	if !ok {
+		m, cmd := m.push(notify.LevelWarning, "Invalid time (HH:MM[:SS] or YYYY-MM-DD HH:MM[:SS])")
+		return m, s.prev, cmd
+	}
+	return m.seekTime(t, false), s.prev, nil
+}
+
+// jumpLayouts are the forms a jump time may take. The time-of-day ones are read on ref's
+// date; the rest are absolute.
+var jumpLayouts = []struct {
+	layout    string
+	timeOfDay bool
+}{
+	{"15:04:05", true},
+	{"15:04", true},
+	{"2006-01-02 15:04:05", false},
+	{"2006-01-02 15:04", false},
+	{time.RFC3339, false},
+}
+
+// parseJumpTime parses v as one of jumpLayouts in ref's location.
+func parseJumpTime(v string, ref time.Time) (time.Time, bool) {
+	for _, l := range jumpLayouts {
+		t, err := time.ParseInLocation(l.layout, v, ref.Location())
+		if err != nil {
+			continue
+		}
+		if l.timeOfDay {
+			y, mo, d := ref.Date()
+			t = time.Date(y, mo, d, t.Hour(), t.Minute(), t.Second(), 0, ref.Location())
+		}
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// renderPickerTimeline is the pure-data picker bar renderer: given the history, the
+// selected index, the pinned baseline index (-1 for none; its timestamp is underlined), and
+// the available width, it builds the horizontal timeline strip. The selected timestamp is
+// followed by its run's cost (runCost) when that fits in half the bar.
+func renderPickerTimeline(history session.History, selected, baseline, width int) string {
+	if history.Len() == 0 {
 		return statusBarStyle.Width(width).Render("")
 	}
 
 	itemWidth := timestampLen + itemSpacing
 
-	timestamp := history[selected].Timestamp.Format(timestampFmt)
-	layout := calcThreeColumnLayout(width, timestampLen)
+	timestamp := history.Timestamp(selected).Format(timestampFmt)
+	if selected == baseline {
+		timestamp = underlineKeep(timestamp)
+	}
+	if cost := runCost(history.Meta(selected)); cost != "" && timestampLen+1+lipgloss.Width(cost) <= width/2 {
+		timestamp += " " + cost
+	}
+	layout := calcThreeColumnLayout(width, lipgloss.Width(timestamp))
 
-	leftItems, rightItems := pickerItems(history, selected, layout.leftWidth-arrowWidth, layout.rightWidth-arrowWidth, itemWidth)
+	leftItems, rightItems := pickerItems(history, selected, baseline, layout.leftWidth-arrowWidth, layout.rightWidth-arrowWidth, itemWidth)
 
 	left := pickerSide(layout.leftWidth, leftItems, selected > len(leftItems), true)
-	right := pickerSide(layout.rightWidth, rightItems, selected+len(rightItems)+1 < len(history), false)
+	right := pickerSide(layout.rightWidth, rightItems, selected+len(rightItems)+1 < history.Len(), false)
 
 	center := pickerSelectedStyle.Render(timestamp)
 	content := lipgloss.JoinHorizontal(lipgloss.Top, left, center, right)
@@ -104,13 +172,21 @@ func renderPickerTimeline(history []session.Execution, selected, width int) stri
 }
 
 // pickerItems returns the timestamps that fit in the left/right sections around the selection.
-func pickerItems(history []session.Execution, selected, leftSpace, rightSpace, itemWidth int) (left, right []string) {
+// The baseline's timestamp is underlined. Only timestamps are read, so a history backed by a
+// recording file decodes nothing but the selected frame.
+func pickerItems(history session.History, selected, baseline, leftSpace, rightSpace, itemWidth int) (left, right []string) {
+	item := func(i int) string {
+		if i == baseline {
+			return underlineKeep(history.Timestamp(i).Format(timestampFmt))
+		}
+		return history.Timestamp(i).Format(timestampFmt)
+	}
 	for i := selected - 1; i >= 0 && leftSpace >= itemWidth; i-- {
-		left = append(left, history[i].Timestamp.Format(timestampFmt))
+		left = append(left, item(i))
 		leftSpace -= itemWidth
 	}
-	for i := selected + 1; i < len(history) && rightSpace >= itemWidth; i++ {
-		right = append(right, history[i].Timestamp.Format(timestampFmt))
+	for i := selected + 1; i < history.Len() && rightSpace >= itemWidth; i++ {
+		right = append(right, item(i))
 		rightSpace -= itemWidth
 	}
 	return left, right
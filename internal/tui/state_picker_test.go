@@ -67,7 +67,7 @@ func TestHandlePickerKey(t *testing.T) {
 
 // An empty history yields a blank, width-sized bar (no panic, no out-of-range access).
 func TestRenderPickerTimelineEmpty(t *testing.T) {
-	if got, want := renderPickerTimeline(nil, 0, 40), statusBarStyle.Width(40).Render(""); got != want {
+	if got, want := renderPickerTimeline(session.NewMemoryHistory(), 0, -1, 40), statusBarStyle.Width(40).Render(""); got != want {
 		t.Errorf("renderPickerTimeline(empty)=%q want %q", got, want)
 	}
 }
@@ -81,7 +81,7 @@ func TestRenderPickerTimelineShowsTimestamps(t *testing.T) {
 		history[i] = session.Execution{Timestamp: base.Add(time.Duration(i) * time.Second)}
 	}
 
-	out := renderPickerTimeline(history, 2, width)
+	out := renderPickerTimeline(session.NewMemoryHistory(history...), 2, -1, width)
 
 	if w := lipgloss.Width(out); w != width {
 		t.Errorf("rendered width=%d want %d", w, width)
@@ -105,7 +105,7 @@ func TestPickerStateBodyReturnsFrame(t *testing.T) {
 	if !ok {
 		t.Fatalf("pickerState.Body: ok=false with history present")
 	}
-	if want := m.frames.Frame(m.cursor.Index(), m.prefs.Diff); body != want {
+	if want := m.frames.Frame(m.cursor.Index(), m.prefs); body != want {
 		t.Errorf("pickerState.Body returned different body than frames.Frame")
 	}
 }
@@ -152,3 +152,33 @@ func TestPickerStateHandleEscReturnsToView(t *testing.T) {
 		t.Errorf("pickerState.Handle(Esc) returned %T, want viewState", st)
 	}
 }
+
+// j in the picker opens a time prompt; a time of day lands on the last frame taken at or
+// before it on the selected frame's date, an absolute time anywhere, and an unparsable one
+// leaves the cursor where it was.
+func TestPickerJumpToTime(t *testing.T) {
+	m := replayAt("x", 0, 10) // frames at 12:00:00 .. 12:00:09 UTC
+	m = feed(t, m, tea.WindowSizeMsg{Width: 80, Height: 10})
+	m = pressKey(t, m, 'b')
+
+	for _, c := range []struct {
+		in   string
+		want int
+	}{
+		{"12:00:04", 4},
+		{"12:00", 0},
+		{"11:00", 0},
+		{"2026-05-30 12:00:07", 7},
+		{"2026-05-30T12:30:00Z", 9},
+		{"noon", 9},
+	} {
+		m = pressKey(t, m, 'j')
+		m = submitInputValue(t, m, c.in)
+		if _, ok := m.state.(pickerState); !ok {
+			t.Fatalf("%q: state %T, want pickerState", c.in, m.state)
+		}
+		if got := m.cursor.Index(); got != c.want {
+			t.Errorf("%q: cursor %d, want %d", c.in, got, c.want)
+		}
+	}
+}
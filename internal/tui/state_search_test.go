@@ -131,7 +131,7 @@ func TestSearchFreezeDuringExecResult(t *testing.T) {
 	m = submitInputValue(t, m, "Pending")
 
 	before := strings.Clone(m.state.(searchState).body)
-	historyLenBefore := len(m.session.History)
+	historyLenBefore := m.session.History.Len()
 
 	m = feed(t, m, execResultMsg{exec: session.Execution{
 		Timestamp: time.Date(2026, 5, 30, 12, 0, 1, 0, time.UTC),
@@ -144,7 +144,7 @@ func TestSearchFreezeDuringExecResult(t *testing.T) {
 	if got := m.state.(searchState).body; got != before {
 		t.Errorf("frozen body changed after execResultMsg")
 	}
-	if len(m.session.History) == historyLenBefore {
+	if m.session.History.Len() == historyLenBefore {
 		t.Errorf("history should still grow during searchState; remained %d", historyLenBefore)
 	}
 }
@@ -178,7 +178,7 @@ func TestSearchEscRestoresLiveTail(t *testing.T) {
 	}
 	if m.cursor.Index() == indexAtSearchStart {
 		t.Errorf("after Esc, historyIndex = %d (stuck at search-start); should advance to %d",
-			m.cursor.Index(), len(m.session.History)-1)
+			m.cursor.Index(), m.session.History.Len()-1)
 	}
 }
 
@@ -282,7 +282,7 @@ func TestSearchSubmitFromSearchModeReplacesOnStack(t *testing.T) {
 // with the selected match wrapped in the searchrender overlay.
 func TestSearchStateBodyReturnsOverlay(t *testing.T) {
 	m := makePaintModel(t)
-	body := m.frames.Frame(m.cursor.Index(), m.prefs.Diff)
+	body := m.frames.Frame(m.cursor.Index(), m.prefs)
 	ss := searchState{
 		query:    "pod-15",
 		body:     body,
@@ -12,7 +12,7 @@ import (
 // matching the no-cursor case.
 func (viewState) Body(m Model) (string, bool) {
 	i, ok := m.cursor.At()
-	return m.frames.Frame(i, m.prefs.Diff), ok
+	return m.frames.Frame(i, m.prefs), ok
 }
 
 // Timestamp returns the timestamp of the frame at the cursor position. Reports ok=false
@@ -22,7 +22,7 @@ func (viewState) Timestamp(m Model) (time.Time, bool) {
 	if !ok {
 		return time.Time{}, false
 	}
-	return m.session.History[i].Timestamp, true
+	return m.session.History.Timestamp(i), true
 }
 
 // ShowsBar returns false because viewState's bar visibility depends only on
@@ -43,7 +43,7 @@ func (viewState) FollowsTail(wasAtTail bool) bool { return wasAtTail }
 // renderBarLayout → renderLeft already truncates to its leftWidth slot, so no
 // pre-truncate is needed here.
 func (viewState) RenderBar(m Model) string {
-	return m.renderBarLayout(m.session.Command, m.renderIndicator(), renderHelp(viewHelpBindings(m)))
+	return m.renderBarLayout(m.barTitle(), m.renderIndicator(), renderHelp(viewHelpBindings(m)))
 }
 
 // Handle processes a key for viewState. Common bindings (diff/pause/record/
@@ -56,12 +56,12 @@ func (s viewState) Handle(m Model, msg tea.KeyPressMsg) (Model, state, tea.Cmd,
 	}
 	switch {
 	case key.Matches(msg, viewKeys.Picker):
-		if len(m.session.History) == 0 {
+		if m.session.History.Len() == 0 {
 			return m, s, nil, true
 		}
 		return m, pickerState{}, nil, true
 	case key.Matches(msg, commonKeys.Escape):
-		n := len(m.session.History)
+		n := m.session.History.Len()
 		if !m.cursor.Following(n) {
 			return m.withCursor(n - 1), s, nil, true
 		}
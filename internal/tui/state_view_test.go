@@ -19,7 +19,7 @@ func TestViewStateBodyReturnsFrame(t *testing.T) {
 	if !ok {
 		t.Fatalf("viewState.Body: ok=false with history present")
 	}
-	if want := m.frames.Frame(m.cursor.Index(), m.prefs.Diff); body != want {
+	if want := m.frames.Frame(m.cursor.Index(), m.prefs); body != want {
 		t.Errorf("viewState.Body returned different body than frames.Frame")
 	}
 }
@@ -61,6 +61,10 @@ var (
 	errorStyle = lipgloss.NewStyle().
 			Foreground(ansi.Red)
 
+	// timeoutStyle sets a killed run apart from one that failed on its own.
+	timeoutStyle = lipgloss.NewStyle().
+			Foreground(ansi.Yellow)
+
 	// recStyle: same 1-cell invariant as indicatorStyle. Red dot on the bar's own plate, not
 	// a red slab — the glyph itself signals recording, the surrounding bg stays consistent.
 	recStyle = lipgloss.NewStyle().
@@ -84,3 +88,9 @@ var (
 func boldKeep(s string) string {
 	return "\x1b[1m" + s + "\x1b[22m"
 }
+
+// underlineKeep is boldKeep for underline (SGR 4, ended by 24): marks the pinned baseline
+// in the picker timeline without tearing the plate.
+func underlineKeep(s string) string {
+	return "\x1b[4m" + s + "\x1b[24m"
+}
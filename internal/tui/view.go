@@ -7,9 +7,22 @@ import (
 	"github.com/ivoronin/wch/internal/tui/notify"
 )
 
-// View renders the UI. Layer order: viewport → bar → help overlay (when toggled) → notify
-// bubbles. Notify draws last so a transient bubble still surfaces over the help panel.
+// View renders the UI.
 func (m Model) View() tea.View {
+	v := tea.NewView(m.render())
+	v.AltScreen = true
+	if m.isLive() {
+		v.WindowTitle = "wch: " + m.session.Command
+	} else {
+		v.WindowTitle = "wch (replay): " + m.session.Command
+	}
+	return v
+}
+
+// render draws the model's width×height area, which Multi tiles. Layer order: viewport →
+// bar → help overlay (when toggled) → notify bubbles. Notify draws last so a transient
+// bubble still surfaces over the help panel.
+func (m Model) render() string {
 	var content string
 	if !m.ready {
 		content = "Initializing..."
@@ -41,13 +54,5 @@ func (m Model) View() tea.View {
 			content = m.notify.Overlay(content, m.width, m.height, insets)
 		}
 	}
-
-	v := tea.NewView(content)
-	v.AltScreen = true
-	if m.isLive() {
-		v.WindowTitle = "wch: " + m.session.Command
-	} else {
-		v.WindowTitle = "wch (replay): " + m.session.Command
-	}
-	return v
+	return content
 }